@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jiujuan/go-star/pkg/config"
+)
+
+var configCmd = command{
+	name:  "config",
+	usage: "config print [-sources]|validate|sample|encrypt|keygen: inspect the loaded configuration, generate a sample, manage encrypted values",
+	run: func(args []string) error {
+		sub, rest, err := subcommand(args, "print", "validate", "sample", "encrypt", "keygen")
+		if err != nil {
+			return err
+		}
+
+		switch sub {
+		case "sample":
+			// 由各模块注册的配置节生成，不需要加载配置
+			_, err := os.Stdout.Write(config.Sample())
+			return err
+		case "encrypt":
+			return encryptValue(rest)
+		case "keygen":
+			key, err := config.GenerateKey()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s=%s\n", config.EnvMasterKey, key)
+			return nil
+		}
+
+		fs := flag.NewFlagSet("config "+sub, flag.ExitOnError)
+		sources := fs.Bool("sources", false, "list every key with the file or environment variable it came from")
+		_ = fs.Parse(rest)
+
+		if _, err := config.Load(configPath); err != nil {
+			return err
+		}
+		switch sub {
+		case "print":
+			// 敏感字段已脱敏
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if *sources {
+				return enc.Encode(config.Settings())
+			}
+			return enc.Encode(config.Dump())
+		default:
+			fmt.Println("config OK")
+			return nil
+		}
+	},
+}
+
+// encryptValue 用 GOSTAR_MASTER_KEY 加密 -value 或标准输入的第一行，输出 enc:... 供写入配置
+func encryptValue(args []string) error {
+	fs := flag.NewFlagSet("config encrypt", flag.ExitOnError)
+	value := fs.String("value", "", "plaintext to encrypt; read from stdin when empty")
+	_ = fs.Parse(args)
+
+	key, err := config.MasterKey()
+	if err != nil {
+		return fmt.Errorf("%w (generate one with: config keygen)", err)
+	}
+
+	plain := *value
+	if plain == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return errors.New("no value given: use -value or pipe it on stdin")
+		}
+		plain = strings.TrimRight(line, "\r\n")
+	}
+
+	out, err := config.Encrypt(plain, key)
+	if err != nil {
+		return err
+	}
+	fmt.Println(out)
+	return nil
+}
@@ -1,13 +1,74 @@
 package main
 
 import (
-	"github.com/jiujuan/go-star/internal/app"
-	"github.com/jiujuan/go-star/internal/router"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
 )
 
+// command 一个子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+// configPath 配置目录，由全局参数 -c 指定
+var configPath string
+
+var commands = []command{
+	serveCmd,
+	migrateCmd,
+	routesCmd,
+	configCmd,
+	userCmd,
+}
+
 func main() {
-	app.Bootstrap(
-		app.Modules,
-		router.Module,
-	)
-}
\ No newline at end of file
+	fs := flag.NewFlagSet("app", flag.ExitOnError)
+	fs.StringVar(&configPath, "c", "./config", "config directory")
+	fs.Usage = usage
+	_ = fs.Parse(os.Args[1:])
+
+	args := fs.Args()
+	if len(args) == 0 {
+		// 不带子命令时保持原来的行为：启动服务
+		args = []string{serveCmd.name}
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			if err := cmd.run(args[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	var b strings.Builder
+	b.WriteString("Usage: app [-c config_dir] <command> [args]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+// subcommand 从 args 中取出二级子命令
+func subcommand(args []string, allowed ...string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("missing subcommand, want one of: %s", strings.Join(allowed, ", "))
+	}
+	for _, a := range allowed {
+		if args[0] == a {
+			return a, args[1:], nil
+		}
+	}
+	return "", nil, fmt.Errorf("unknown subcommand %q, want one of: %s", args[0], strings.Join(allowed, ", "))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"gorm.io/gorm"
+
+	"github.com/jiujuan/go-star/internal/app"
+	"github.com/jiujuan/go-star/internal/module"
+	"github.com/jiujuan/go-star/pkg/cache"
+	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/db"
+	"github.com/jiujuan/go-star/pkg/jwt"
+)
+
+var migrateCmd = command{
+	name:  "migrate",
+	usage: "migrate up|down|status: manage database tables",
+	run: func(args []string) error {
+		sub, rest, err := subcommand(args, "up", "down", "status")
+		if err != nil {
+			return err
+		}
+		fs := flag.NewFlagSet("migrate "+sub, flag.ExitOnError)
+		yes := fs.Bool("yes", false, "confirm dropping tables (down only)")
+		_ = fs.Parse(rest)
+
+		if sub == "down" && !*yes {
+			return fmt.Errorf("migrate down drops all tables, re-run with -yes to confirm")
+		}
+		if _, err := config.Load(configPath); err != nil {
+			return err
+		}
+
+		// 模型由各业务模块提供；只连接 DB，不会打开 HTTP 监听，也不会连接 Redis
+		return app.Exec(func(d *db.DB, p module.Params) error {
+			ctx := context.Background()
+			models := p.Models()
+			switch sub {
+			case "up":
+				return migrateUp(ctx, d, models)
+			case "down":
+				return migrateDown(ctx, d, models)
+			default:
+				return migrateStatus(ctx, d, models)
+			}
+		}, app.Base, db.Module, cache.Module, jwt.Module, app.Domains)
+	},
+}
+
+func migrateUp(ctx context.Context, d *db.DB, models []interface{}) error {
+	for _, m := range models {
+		if err := d.WithContext(ctx).AutoMigrate(m); err != nil {
+			return fmt.Errorf("migrate %s: %w", tableName(d, m), err)
+		}
+		fmt.Printf("migrated %s\n", tableName(d, m))
+	}
+	return nil
+}
+
+func migrateDown(ctx context.Context, d *db.DB, models []interface{}) error {
+	// 倒序删除，先删引用方
+	for i := len(models) - 1; i >= 0; i-- {
+		if err := d.WithContext(ctx).Migrator().DropTable(models[i]); err != nil {
+			return fmt.Errorf("drop %s: %w", tableName(d, models[i]), err)
+		}
+		fmt.Printf("dropped %s\n", tableName(d, models[i]))
+	}
+	return nil
+}
+
+func migrateStatus(ctx context.Context, d *db.DB, models []interface{}) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tSTATUS")
+	for _, m := range models {
+		status := "pending"
+		if d.WithContext(ctx).Migrator().HasTable(m) {
+			status = "applied"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", tableName(d, m), status)
+	}
+	return w.Flush()
+}
+
+// tableName 解析模型对应的表名
+func tableName(d *db.DB, m interface{}) string {
+	stmt := &gorm.Statement{DB: d.DB}
+	if err := stmt.Parse(m); err != nil {
+		return fmt.Sprintf("%T", m)
+	}
+	return stmt.Schema.Table
+}
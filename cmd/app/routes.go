@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+
+	"github.com/jiujuan/go-star/internal/app"
+	"github.com/jiujuan/go-star/internal/router"
+	"github.com/jiujuan/go-star/pkg/cache"
+	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/db"
+	"github.com/jiujuan/go-star/pkg/jwt"
+	"github.com/jiujuan/go-star/pkg/redis"
+)
+
+var routesCmd = command{
+	name:  "routes",
+	usage: "print the registered HTTP routes",
+	run: func(args []string) error {
+		if _, err := config.Load(configPath); err != nil {
+			return err
+		}
+
+		// 打印路由只需要构造 handler，数据源用空值代替，不会连接 MySQL/Redis
+		stubs := fx.Provide(
+			func() *db.DB { return nil },
+			func() *redis.Client { return nil },
+		)
+		return app.Exec(func(a *app.App, _ *gin.Engine) error {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "SERVER\tMETHOD\tPATH\tHANDLER")
+			printRoutes(w, "public", a.Router)
+			if a.Admin != nil {
+				printRoutes(w, "admin", a.Admin)
+			}
+			return w.Flush()
+		},
+			app.Base,
+			app.Core,
+			stubs,
+			cache.Module,
+			jwt.Module,
+			app.HealthModule,
+			app.AdminModule,
+			app.Services,
+			router.Module,
+		)
+	},
+}
+
+func printRoutes(w io.Writer, server string, e *gin.Engine) {
+	for _, r := range e.Routes() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", server, r.Method, r.Path, r.Handler)
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/jiujuan/go-star/internal/app"
+	"github.com/jiujuan/go-star/internal/router"
+	"github.com/jiujuan/go-star/pkg/config"
+)
+
+var serveCmd = command{
+	name:  "serve",
+	usage: "start the HTTP server (default)",
+	run: func(args []string) error {
+		config.Init(configPath)
+		app.Bootstrap(
+			app.Modules,
+			router.Module,
+		)
+		return nil
+	},
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jiujuan/go-star/internal/app"
+	"github.com/jiujuan/go-star/internal/service"
+	"github.com/jiujuan/go-star/pkg/cache"
+	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/db"
+	"github.com/jiujuan/go-star/pkg/jwt"
+)
+
+var userCmd = command{
+	name:  "user",
+	usage: "user create-admin -username NAME [-password PASS]: manage users",
+	run: func(args []string) error {
+		_, rest, err := subcommand(args, "create-admin")
+		if err != nil {
+			return err
+		}
+		fs := flag.NewFlagSet("user create-admin", flag.ExitOnError)
+		username := fs.String("username", "", "admin username")
+		password := fs.String("password", "", "admin password, defaults to $GOSTAR_ADMIN_PASSWORD")
+		_ = fs.Parse(rest)
+
+		if *password == "" {
+			*password = os.Getenv("GOSTAR_ADMIN_PASSWORD")
+		}
+		if *username == "" || *password == "" {
+			return fmt.Errorf("-username and -password (or GOSTAR_ADMIN_PASSWORD) are required")
+		}
+		if _, err := config.Load(configPath); err != nil {
+			return err
+		}
+
+		return app.Exec(func(svc *service.UserService) error {
+			u, err := svc.CreateAdmin(context.Background(), *username, *password)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("admin %s created, id=%d\n", u.Username, u.ID)
+			return nil
+		},
+			app.Base,
+			db.Module,
+			cache.Module,
+			jwt.Module,
+			app.Domains,
+		)
+	},
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/fx"
+
+	"github.com/jiujuan/go-star/internal/middleware"
+	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+const (
+	defaultAdminAddress = "127.0.0.1"
+	defaultAdminPort    = 9090
+)
+
+// newAdmin 创建运维端口的引擎和 server，超时沿用主服务配置。
+// 运维端口没有认证，缺省只监听本机。
+func newAdmin(sc config.ServerConfig) (*gin.Engine, *http.Server) {
+	engine := gin.New()
+	engine.Use(middleware.Recover())
+
+	srv := newServer(sc, engine)
+	host := sc.Admin.Address
+	if host == "" {
+		host = defaultAdminAddress
+	}
+	srv.Addr = net.JoinHostPort(host, strconv.Itoa(intOr(sc.Admin.Port, defaultAdminPort)))
+	// pprof 的 profile/trace 会持续写响应数十秒，不设置写超时
+	srv.WriteTimeout = 0
+	return engine, srv
+}
+
+// registerAdminRoutes 挂载指标、pprof、日志级别和配置查看，只在运维端口上暴露
+func registerAdminRoutes(app *App) {
+	if app.Admin == nil {
+		return
+	}
+	r := app.Admin
+
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	if app.Config.Server.Admin.Pprof {
+		pp := r.Group("/debug/pprof")
+		pp.GET("/", gin.WrapF(pprof.Index))
+		pp.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		pp.GET("/profile", gin.WrapF(pprof.Profile))
+		pp.POST("/symbol", gin.WrapF(pprof.Symbol))
+		pp.GET("/symbol", gin.WrapF(pprof.Symbol))
+		pp.GET("/trace", gin.WrapF(pprof.Trace))
+		pp.GET("/:name", func(c *gin.Context) {
+			pprof.Handler(c.Param("name")).ServeHTTP(c.Writer, c.Request)
+		})
+	}
+
+	r.GET("/log/level", getLogLevel)
+	r.PUT("/log/level", setLogLevel)
+	r.GET("/log/levels", func(c *gin.Context) {
+		c.JSON(http.StatusOK, logger.Levels())
+	})
+
+	// 配置均已脱敏；/config/sources 同时给出每个 key 来自哪个文件或环境变量
+	r.GET("/config", func(c *gin.Context) {
+		c.JSON(http.StatusOK, config.Dump())
+	})
+	r.GET("/config/sources", func(c *gin.Context) {
+		c.JSON(http.StatusOK, config.Settings())
+	})
+}
+
+// getLogLevel GET /log/level
+func getLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": logger.GetLevel()})
+}
+
+// setLogLevel PUT /log/level?level=debug&module=db&ttl=10m
+// module 为空调整全局级别，level=inherit 让模块恢复跟随全局；
+// 不带 ttl 时按 log.level_ttl 自动恢复，ttl=0 表示不恢复
+func setLogLevel(c *gin.Context) {
+	module, level := c.Query("module"), c.Query("level")
+	ttl := config.LogSection.Get().LevelTTL
+	if s, ok := c.GetQuery("ttl"); ok {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": fmt.Sprintf("invalid ttl %q", s)})
+			return
+		}
+		ttl = d
+	}
+	if module == "" && level == logger.Inherit {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "inherit requires module"})
+		return
+	}
+	// 只能调整已有的模块，写错的模块名不会新建一个没人使用的 logger
+	if _, ok := levelInfo(module); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"msg": fmt.Sprintf("unknown module %q", module)})
+		return
+	}
+	if err := logger.SetModuleLevel(module, level, ttl); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	logger.Warnf("log level of %q changed to %s via admin endpoint (ttl %s)", module, level, ttl)
+	info, _ := levelInfo(module)
+	c.JSON(http.StatusOK, info)
+}
+
+// levelInfo 在 logger.Levels() 中查找模块，module 为空表示全局
+func levelInfo(module string) (logger.LevelInfo, bool) {
+	for _, info := range logger.Levels() {
+		if info.Module == module {
+			return info, true
+		}
+	}
+	return logger.LevelInfo{}, false
+}
+
+// AdminModule 运维端口路由
+var AdminModule = fx.Invoke(registerAdminRoutes)
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+func TestAdmin(t *testing.T) {
+	Convey("运维端口", t, func() {
+		Convey("缺省只监听本机", func() {
+			_, srv := newAdmin(config.ServerConfig{})
+			So(srv.Addr, ShouldEqual, "127.0.0.1:9090")
+			_, srv = newAdmin(config.ServerConfig{Admin: config.AdminConfig{Address: "0.0.0.0", Port: 9191}})
+			So(srv.Addr, ShouldEqual, "0.0.0.0:9191")
+		})
+
+		Convey("调整日志级别", func() {
+			logger.L = logrus.New()
+			logger.L.SetOutput(io.Discard)
+			logger.Named("db")
+			r := gin.New()
+			r.PUT("/log/level", setLogLevel)
+			put := func(query string) int {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/log/level?"+query, nil))
+				return w.Code
+			}
+
+			So(put("module=db&level=debug&ttl=0"), ShouldEqual, http.StatusOK)
+			So(logger.Named("db").Logger.GetLevel(), ShouldEqual, logrus.DebugLevel)
+			So(put("module=nosuch&level=debug"), ShouldEqual, http.StatusNotFound)
+			for _, info := range logger.Levels() {
+				So(info.Module, ShouldNotEqual, "nosuch")
+			}
+			So(put("module=db&level=inherit&ttl=0"), ShouldEqual, http.StatusOK)
+		})
+	})
+}
@@ -2,45 +2,329 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/fx"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/jiujuan/go-star/internal/middleware"
+	"github.com/jiujuan/go-star/pkg/certs"
+	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/graceful"
+	"github.com/jiujuan/go-star/pkg/listener"
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+// 服务器参数缺省值，配置中未填写（零值）时使用
+const (
+	defaultReadTimeout       = 30 * time.Second
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultWriteTimeout      = 30 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+	defaultMaxHeaderBytes    = 1 << 20 // 1MB
+	defaultShutdownTimeout   = 30 * time.Second
 )
 
+// Plugin 插件：在 Init 中读取自己的配置、挂载路由和中间件、注册生命周期 hook
 type Plugin interface {
 	Name() string
 	Init(*App) error
 }
 
 type App struct {
-	Router *gin.Engine
-	LC     fx.Lifecycle
+	Router   *gin.Engine
+	Server   *http.Server
+	Config   *config.Config
+	LC       fx.Lifecycle
+	Graceful *graceful.Manager
+
+	// Admin 运维端口的 gin 引擎，server.admin.enable 为 false 时为 nil
+	Admin       *gin.Engine
+	AdminServer *http.Server
+
+	// Listeners 创建或继承监听 socket（systemd、热升级）
+	Listeners *listener.Manager
+
+	certs   *certs.Reloader // 启用 TLS 时负责证书热加载
+	workers *supervisor     // 通过 Go 注册的后台任务
 }
 
+// Core App、插件和 gin 引擎，不包含 HTTP 监听
+var Core = fx.Options(
+	fx.Provide(NewApp),
+	fx.Provide(initPlugins),
+	// 业务路由通过 *gin.Engine 注册，依赖插件注册表以保证插件先初始化
+	fx.Provide(func(a *App, _ *PluginRegistry) *gin.Engine { return a.Router }),
+)
+
 func Bootstrap(opts ...fx.Option) {
 	fx.New(
-		fx.Provide(NewApp),
+		fx.StopTimeout(stopTimeout()),
+		graceful.Module,
+		Core,
 		fx.Options(opts...),
+		// 没有业务路由时也要初始化插件；放在 opts 之后，确保日志等已初始化
+		fx.Invoke(func(*PluginRegistry) {}),
 		fx.Invoke(registerHooks),
 	).Run()
 }
 
-func NewApp() *App {
-	gin.SetMode(gin.ReleaseMode)
-	return &App{Router: gin.New()}
+// Exec 运行一次性命令：只构建 opts 中的模块，执行 fn 后按生命周期释放资源，不启动 HTTP 监听。
+// fn 是 fx.Invoke 形式的函数，参数由 fx 注入，可以返回 error。
+func Exec(fn interface{}, opts ...fx.Option) error {
+	a := fx.New(
+		fx.NopLogger,
+		graceful.Module,
+		fx.Options(opts...),
+		fx.Invoke(fn),
+	)
+	if err := a.Err(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout())
+	defer cancel()
+	if err := a.Start(ctx); err != nil {
+		return err
+	}
+	return a.Stop(ctx)
+}
+
+// NewApp 根据配置创建 gin 引擎（已挂载全局中间件）和 http.Server
+func NewApp(lc fx.Lifecycle, cfg *config.Config, gm *graceful.Manager) (*App, error) {
+	mode := cfg.Server.Mode
+	if mode == "" {
+		mode = gin.ReleaseMode
+	}
+	gin.SetMode(mode)
+
+	listeners, err := listener.NewManager(cfg.Server.Listen.ReusePort)
+	if err != nil {
+		return nil, err
+	}
+
+	// 全局中间件在插件初始化之前挂载：gin 注册路由时复制当前的中间件链，
+	// 插件在 Init 中注册的路由和中间件都要在 Recover、RequestID 之内
+	router := gin.New()
+	router.Use(
+		middleware.RequestID(),
+		middleware.LogContext(),
+		middleware.AccessLog(),
+		middleware.Recover(),
+		middleware.CORS(),
+		middleware.ClientIdentity(),
+	)
+	a := &App{
+		Router:    router,
+		Server:    newServer(cfg.Server, router),
+		Config:    cfg,
+		LC:        lc,
+		Graceful:  gm,
+		Listeners: listeners,
+		workers:   newSupervisor(),
+	}
+	if addr := cfg.Server.Listen.Address; addr != "" {
+		a.Server.Addr = addr
+	}
+	switch {
+	case cfg.Server.TLS.Enable:
+		if err := a.setupTLS(cfg.Server.TLS); err != nil {
+			return nil, err
+		}
+	case cfg.Server.H2C:
+		// 明文 HTTP/2，只适合内网服务之间调用
+		a.Server.Handler = h2c.NewHandler(router, &http2.Server{})
+	}
+	if cfg.Server.Admin.Enable {
+		a.Admin, a.AdminServer = newAdmin(cfg.Server)
+	}
+	return a, nil
+}
+
+// setupTLS 加载证书并配置 (双向) TLS，证书通过 GetCertificate 动态获取
+func (a *App) setupTLS(tc config.TLSConfig) error {
+	r, err := certs.NewReloader(tc.CertFile, tc.KeyFile, tc.ClientCAFile)
+	if err != nil {
+		return err
+	}
+	mode := tc.ClientAuth
+	if mode == "" && tc.ClientCAFile != "" {
+		mode = "require_and_verify"
+	}
+	clientAuth, err := certs.ParseClientAuth(mode)
+	if err != nil {
+		return err
+	}
+	minVersion, err := certs.ParseVersion(tc.MinVersion)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := r.TLSConfig(clientAuth, minVersion)
+	if err != nil {
+		return err
+	}
+	a.certs = r
+	a.Server.TLSConfig = tlsConfig
+	return nil
+}
+
+// InternalRouter 内部端点挂载的引擎：启用运维端口时为 Admin，否则为公共引擎
+func (a *App) InternalRouter() *gin.Engine {
+	if a.Admin != nil {
+		return a.Admin
+	}
+	return a.Router
+}
+
+// newServer 按配置构造 http.Server，未配置的超时使用缺省值
+func newServer(sc config.ServerConfig, h http.Handler) *http.Server {
+	port := sc.Port
+	if port == 0 {
+		port = 8080
+	}
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           h,
+		ReadTimeout:       durationOr(sc.ReadTimeout, defaultReadTimeout),
+		ReadHeaderTimeout: durationOr(sc.ReadHeaderTimeout, defaultReadHeaderTimeout),
+		WriteTimeout:      durationOr(sc.WriteTimeout, defaultWriteTimeout),
+		IdleTimeout:       durationOr(sc.IdleTimeout, defaultIdleTimeout),
+		MaxHeaderBytes:    intOr(sc.MaxHeaderBytes, defaultMaxHeaderBytes),
+	}
+}
+
+func registerHooks(lc fx.Lifecycle, app *App, shutdowner fx.Shutdowner) {
+	timeout := durationOr(app.Config.Server.ShutdownTimeout, defaultShutdownTimeout)
+	app.Graceful.SetTimeout(timeout)
+
+	appendServer(lc, app, shutdowner, "http", app.Server, timeout)
+	if app.AdminServer != nil {
+		appendServer(lc, app, shutdowner, "admin", app.AdminServer, timeout)
+	}
+	if app.certs != nil {
+		watchCerts(lc, app.certs, app.Config.Server.TLS.ReloadInterval)
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			// 全部 server 已开始监听；若由热升级启动，通知父进程可以退出
+			if err := listener.NotifyReady(); err != nil {
+				logger.Errorf("notify parent process ready: %v", err)
+			}
+			startWorkers(app)
+			return app.Listeners.Close()
+		},
+	})
+	if app.Config.Server.Listen.Upgrade {
+		watchUpgrade(lc, app.Listeners, shutdowner, app.Config.Server.Listen.UpgradeTimeout)
+	}
 }
 
-func registerHooks(lc fx.Lifecycle, app *App) {
+// appendServer 在 OnStart 中监听并启动 srv，停止时由 graceful 在 drain 阶段关闭
+func appendServer(lc fx.Lifecycle, app *App, shutdowner fx.Shutdowner, name string, srv *http.Server, timeout time.Duration) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			go app.Router.Run(":8080")
+			// 同步监听端口，端口占用等错误直接返回给 fx，启动失败
+			ln, err := app.Listeners.Listen(name, srv.Addr)
+			if err != nil {
+				return fmt.Errorf("%s server listen %s: %w", name, srv.Addr, err)
+			}
+			if srv.TLSConfig != nil {
+				ln = tls.NewListener(ln, srv.TLSConfig)
+			}
+			logger.Infof("%s server listening on %s (tls=%t)", name, ln.Addr(), srv.TLSConfig != nil)
+
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.Errorf("%s server serve error: %v", name, err)
+					_ = shutdowner.Shutdown(fx.ExitCode(1))
+				}
+			}()
+
+			// 在期限内等待处理中的请求完成
+			app.Graceful.Register(name+"-server", graceful.HTTPServerShutdown(srv).WithTimeout(timeout))
+			return nil
+		},
+	})
+}
+
+// watchUpgrade 收到 SIGHUP 时把监听 socket 交给新进程，新进程就绪后当前进程优雅退出
+func watchUpgrade(lc fx.Lifecycle, lm *listener.Manager, shutdowner fx.Shutdowner, timeout time.Duration) {
+	hup := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			signal.Notify(hup, syscall.SIGHUP)
+			go func() {
+				for {
+					select {
+					case <-done:
+						return
+					case <-hup:
+						logger.Infof("received SIGHUP, starting binary upgrade")
+						if err := lm.Upgrade(timeout); err != nil {
+							logger.Errorf("binary upgrade failed, keep serving: %v", err)
+							continue
+						}
+						logger.Infof("new process is ready, draining current process")
+						_ = shutdowner.Shutdown()
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			signal.Stop(hup)
+			close(done)
+			return nil
+		},
+	})
+}
+
+// watchCerts 运行期间定期检查证书文件，停止时退出
+func watchCerts(lc fx.Lifecycle, r *certs.Reloader, interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go r.Watch(ctx, interval)
 			return nil
 		},
-		OnStop: func(ctx context.Context) error {
-			return nil // graceful shutdown
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
 		},
 	})
-}
\ No newline at end of file
+}
+
+// stopTimeout fx 整体停止超时，需要比 HTTP 排空时间略长
+func stopTimeout() time.Duration {
+	timeout := defaultShutdownTimeout
+	if config.C != nil {
+		timeout = durationOr(config.C.Server.ShutdownTimeout, defaultShutdownTimeout)
+	}
+	return timeout + 5*time.Second
+}
+
+func durationOr(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+func intOr(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
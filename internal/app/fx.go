@@ -1,11 +1,7 @@
 package app
 
 import (
-	"github.com/jiujuan/go-star/internal/handler"
-	"github.com/jiujuan/go-star/internal/middleware"
-	"github.com/jiujuan/go-star/internal/repository"
 	"github.com/jiujuan/go-star/internal/router"
-	"github.com/jiujuan/go-star/internal/service"
 	"github.com/jiujuan/go-star/pkg/cache"
 	"github.com/jiujuan/go-star/pkg/config"
 	"github.com/jiujuan/go-star/pkg/db"
@@ -15,16 +11,32 @@ import (
 	"go.uber.org/fx"
 )
 
-var Modules = fx.Options(
+// Base 所有命令都需要的配置与日志
+var Base = fx.Options(
 	config.Module,
 	logger.Module,
+)
+
+// Domains 业务模块，新增业务域只需在这里加一行
+var Domains = fx.Options(
+	router.Auth,
+)
+
+// Services 业务模块及其路由、健康检查
+var Services = fx.Options(
+	Domains,
+	fx.Provide(router.NewRouter),
+	fx.Invoke(registerModules),
+)
+
+// Modules 启动 HTTP 服务需要的全部模块
+var Modules = fx.Options(
+	Base,
 	db.Module,
 	redis.Module,
 	cache.Module,
 	jwt.Module,
-
-	fx.Provide(repository.NewUserRepo),
-	fx.Provide(service.NewUserService),
-	fx.Provide(handler.NewAuthHandler),
-	fx.Provide(router.NewRouter),
-)
\ No newline at end of file
+	HealthModule,
+	AdminModule,
+	Services,
+)
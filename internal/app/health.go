@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+
+	"github.com/jiujuan/go-star/pkg/db"
+	"github.com/jiujuan/go-star/pkg/graceful"
+	"github.com/jiujuan/go-star/pkg/health"
+	"github.com/jiujuan/go-star/pkg/redis"
+	"go.uber.org/fx"
+)
+
+// 服务名与版本，版本可通过 -ldflags "-X github.com/jiujuan/go-star/internal/app.Version=x.y.z" 注入
+var (
+	Name    = "go-star"
+	Version = "1.0.0"
+)
+
+// newHealthManager 创建健康检查管理器，关闭时第一步摘除就绪状态
+func newHealthManager(gm *graceful.Manager) *health.Manager {
+	m := health.NewManager(Name, Version)
+	m.SetShutdownDelay(health.Section.Get().ShutdownDelay)
+	gm.Register("health-readiness", m.ShutdownHook())
+	return m
+}
+
+// registerHealthChecks 注册 MySQL、Redis 探活和后台任务状态
+func registerHealthChecks(m *health.Manager, app *App, d *db.DB, r *redis.Client) {
+	m.Register("database", health.NewDatabaseChecker("database", d.Ping))
+	m.Register("redis", health.NewRedisChecker("redis", func(ctx context.Context) error {
+		return r.Client.Ping(ctx).Err()
+	}))
+	m.Register("workers", app.WorkersChecker())
+}
+
+// registerHealthRoutes 挂载 Kubernetes 探针路由；启用运维端口时只挂在运维端口上
+func registerHealthRoutes(app *App, m *health.Manager) {
+	r := app.InternalRouter()
+	r.GET("/health", m.HTTPHandler())
+	r.GET("/health/ready", m.ReadinessHandler())
+	r.GET("/health/live", m.LivenessHandler())
+}
+
+// HealthModule 健康检查相关的 fx 配置
+var HealthModule = fx.Options(
+	fx.Provide(newHealthManager),
+	fx.Invoke(registerHealthChecks),
+	fx.Invoke(registerHealthRoutes),
+)
@@ -0,0 +1,15 @@
+package app
+
+import (
+	"github.com/jiujuan/go-star/internal/module"
+	"github.com/jiujuan/go-star/pkg/health"
+)
+
+// registerModules 把模块的健康检查注册为 <模块名>.<检查名>
+func registerModules(p module.Params, m *health.Manager) {
+	for _, mod := range p.Sorted() {
+		for name, c := range mod.Checks {
+			m.Register(mod.Name+"."+name, c)
+		}
+	}
+}
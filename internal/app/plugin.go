@@ -0,0 +1,173 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/fx"
+
+	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+// DependentPlugin 声明依赖的插件，依赖会先于自身初始化
+type DependentPlugin interface {
+	Plugin
+	Dependencies() []string
+}
+
+// ProvidePlugin 把插件构造函数加入 "plugins" 组，构造函数的参数由 fx 注入
+//
+//	app.Bootstrap(app.Modules, router.Module, app.ProvidePlugin(metrics.New))
+func ProvidePlugin(constructor interface{}) fx.Option {
+	return fx.Provide(fx.Annotate(
+		constructor,
+		fx.As(new(Plugin)),
+		fx.ResultTags(`group:"plugins"`),
+	))
+}
+
+// PluginRegistry 保存全部插件及其初始化顺序
+type PluginRegistry struct {
+	plugins map[string]Plugin
+	order   []string
+}
+
+// NewPluginRegistry 校验重名、缺失依赖和循环依赖，并计算初始化顺序
+func NewPluginRegistry(plugins ...Plugin) (*PluginRegistry, error) {
+	r := &PluginRegistry{plugins: make(map[string]Plugin, len(plugins))}
+	names := make([]string, 0, len(plugins))
+	for _, p := range plugins {
+		name := p.Name()
+		if _, ok := r.plugins[name]; ok {
+			return nil, fmt.Errorf("plugin %q registered twice", name)
+		}
+		r.plugins[name] = p
+		names = append(names, name)
+	}
+	// 同层插件按名字排序，保证顺序稳定
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(names))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("plugin dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+		state[name] = visiting
+		if dp, ok := r.plugins[name].(DependentPlugin); ok {
+			for _, dep := range dp.Dependencies() {
+				if _, ok := r.plugins[dep]; !ok {
+					return fmt.Errorf("plugin %q depends on unknown plugin %q", name, dep)
+				}
+				if err := visit(dep, append(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+		state[name] = visited
+		r.order = append(r.order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Order 返回初始化顺序
+func (r *PluginRegistry) Order() []string {
+	return append([]string(nil), r.order...)
+}
+
+// Get 按名字获取插件
+func (r *PluginRegistry) Get(name string) (Plugin, bool) {
+	p, ok := r.plugins[name]
+	return p, ok
+}
+
+// InitAll 按依赖顺序初始化插件；plugins.<name>.enabled 为 false 的插件跳过，
+// 直接或间接依赖它的插件也一并跳过
+func (r *PluginRegistry) InitAll(app *App) error {
+	return r.initAll(app, PluginEnabled)
+}
+
+func (r *PluginRegistry) initAll(app *App, enabled func(name string) bool) error {
+	// skipped 被跳过的插件 -> 原因链，如 "audit -> metrics (disabled)"
+	skipped := make(map[string]string)
+	for _, name := range r.order {
+		if !enabled(name) {
+			skipped[name] = name + " (disabled)"
+			logger.Infof("plugin %s disabled by config", name)
+			continue
+		}
+		if dp, ok := r.plugins[name].(DependentPlugin); ok {
+			for _, dep := range dp.Dependencies() {
+				if chain, ok := skipped[dep]; ok {
+					skipped[name] = name + " -> " + chain
+					break
+				}
+			}
+			if chain, ok := skipped[name]; ok {
+				logger.Warnf("plugin %s skipped: dependency disabled: %s", name, chain)
+				continue
+			}
+		}
+		if err := r.plugins[name].Init(app); err != nil {
+			return fmt.Errorf("init plugin %q: %w", name, err)
+		}
+		logger.Infof("plugin %s initialized", name)
+	}
+	return nil
+}
+
+// PluginEnabled 插件是否启用，未配置时默认启用
+func PluginEnabled(name string) bool {
+	var section struct {
+		Enabled *bool `mapstructure:"enabled"`
+	}
+	if err := config.UnmarshalKey(pluginKey(name), &section); err != nil || section.Enabled == nil {
+		return true
+	}
+	return *section.Enabled
+}
+
+// PluginConfig 读取插件自己的配置节 plugins.<name>
+func (a *App) PluginConfig(name string, out interface{}) error {
+	return config.UnmarshalKey(pluginKey(name), out)
+}
+
+func pluginKey(name string) string {
+	return "plugins." + name
+}
+
+type pluginParams struct {
+	fx.In
+
+	App     *App
+	Plugins []Plugin `group:"plugins"`
+}
+
+// initPlugins 构造注册表并初始化全部插件。
+// gin 引擎依赖它，因此插件的中间件一定先于业务路由注册。
+func initPlugins(p pluginParams) (*PluginRegistry, error) {
+	r, err := NewPluginRegistry(p.Plugins...)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.InitAll(p.App); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
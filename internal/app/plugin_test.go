@@ -0,0 +1,118 @@
+package app
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/graceful"
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+type fakePlugin struct {
+	name string
+	deps []string
+}
+
+func (p *fakePlugin) Name() string           { return p.name }
+func (p *fakePlugin) Init(*App) error        { return nil }
+func (p *fakePlugin) Dependencies() []string { return p.deps }
+
+// recordPlugin 记录 Init 调用顺序
+type recordPlugin struct {
+	fakePlugin
+	inited *[]string
+}
+
+func (p *recordPlugin) Init(*App) error {
+	*p.inited = append(*p.inited, p.name)
+	return nil
+}
+
+// routePlugin 在 Init 中注册一个会 panic 的路由
+type routePlugin struct{}
+
+func (routePlugin) Name() string { return "route" }
+func (routePlugin) Init(a *App) error {
+	a.Router.GET("/boom", func(*gin.Context) { panic("boom") })
+	return nil
+}
+
+func TestPluginRegistry(t *testing.T) {
+	logger.L = logrus.New()
+	logger.L.SetOutput(io.Discard)
+
+	Convey("插件注册表测试", t, func() {
+		Convey("依赖先于自身初始化", func() {
+			r, err := NewPluginRegistry(
+				&fakePlugin{name: "admin", deps: []string{"metrics", "audit"}},
+				&fakePlugin{name: "audit", deps: []string{"metrics"}},
+				&fakePlugin{name: "metrics"},
+			)
+			So(err, ShouldBeNil)
+			So(r.Order(), ShouldResemble, []string{"metrics", "audit", "admin"})
+		})
+
+		Convey("无依赖时按名字排序", func() {
+			r, err := NewPluginRegistry(&fakePlugin{name: "b"}, &fakePlugin{name: "a"})
+			So(err, ShouldBeNil)
+			So(r.Order(), ShouldResemble, []string{"a", "b"})
+		})
+
+		Convey("重名插件报错", func() {
+			_, err := NewPluginRegistry(&fakePlugin{name: "a"}, &fakePlugin{name: "a"})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("缺失依赖报错", func() {
+			_, err := NewPluginRegistry(&fakePlugin{name: "a", deps: []string{"missing"}})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "missing")
+		})
+
+		Convey("循环依赖报错", func() {
+			_, err := NewPluginRegistry(
+				&fakePlugin{name: "a", deps: []string{"b"}},
+				&fakePlugin{name: "b", deps: []string{"a"}},
+			)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "cycle")
+		})
+
+		Convey("关闭的插件及直接、间接依赖它的插件都跳过", func() {
+			var inited []string
+			plugin := func(name string, deps ...string) Plugin {
+				return &recordPlugin{fakePlugin: fakePlugin{name: name, deps: deps}, inited: &inited}
+			}
+			r, err := NewPluginRegistry(
+				plugin("metrics"),
+				plugin("audit", "metrics"),
+				plugin("admin", "audit"),
+				plugin("cache"),
+			)
+			So(err, ShouldBeNil)
+			So(r.initAll(&App{}, func(name string) bool { return name != "metrics" }), ShouldBeNil)
+			So(inited, ShouldResemble, []string{"cache"})
+		})
+
+		Convey("插件注册的路由经过全局中间件", func() {
+			a, err := NewApp(fxtest.NewLifecycle(t), &config.Config{}, graceful.NewManager())
+			So(err, ShouldBeNil)
+			r, err := NewPluginRegistry(routePlugin{})
+			So(err, ShouldBeNil)
+			So(r.InitAll(a), ShouldBeNil)
+
+			w := httptest.NewRecorder()
+			a.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom", nil))
+			So(w.Code, ShouldEqual, http.StatusInternalServerError)
+			So(w.Header().Get("X-Request-ID"), ShouldNotBeEmpty)
+		})
+	})
+}
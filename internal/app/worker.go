@@ -0,0 +1,274 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jiujuan/go-star/pkg/graceful"
+	"github.com/jiujuan/go-star/pkg/health"
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+// 重启退避参数缺省值
+const (
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = time.Minute
+)
+
+// WorkerState 后台任务状态
+type WorkerState string
+
+const (
+	WorkerPending  WorkerState = "pending"     // 已注册，等待 HTTP 服务就绪后启动
+	WorkerRunning  WorkerState = "running"     // 运行中
+	WorkerBackoff  WorkerState = "backing_off" // 出错或 panic 后等待重启
+	WorkerFailed   WorkerState = "failed"      // 超过最大重启次数，不再重启
+	WorkerFinished WorkerState = "finished"    // 正常返回或已被取消
+)
+
+// WorkerFunc 后台任务，ctx 在应用停止时取消；返回 error 或 panic 会按退避策略重启
+type WorkerFunc func(ctx context.Context) error
+
+// WorkerOption 后台任务的可选参数
+type WorkerOption func(*worker)
+
+// WithBackoff 设置重启退避的初始值和上限
+func WithBackoff(initial, max time.Duration) WorkerOption {
+	return func(w *worker) {
+		w.initialBackoff, w.maxBackoff = initial, max
+	}
+}
+
+// WithMaxRestarts 连续重启超过 n 次后置为 failed，0 表示不限制
+func WithMaxRestarts(n int) WorkerOption {
+	return func(w *worker) { w.maxRestarts = n }
+}
+
+type worker struct {
+	name           string
+	fn             WorkerFunc
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	maxRestarts    int
+
+	mu       sync.Mutex
+	state    WorkerState
+	restarts int
+	lastErr  error
+}
+
+func (w *worker) setState(s WorkerState, err error) {
+	w.mu.Lock()
+	w.state = s
+	if err != nil {
+		w.lastErr = err
+	}
+	w.mu.Unlock()
+}
+
+// WorkerStatus 后台任务的状态快照
+type WorkerStatus struct {
+	Name     string      `json:"name"`
+	State    WorkerState `json:"state"`
+	Restarts int         `json:"restarts"`
+	LastErr  string      `json:"last_error,omitempty"`
+}
+
+func (w *worker) status() WorkerStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	st := WorkerStatus{Name: w.name, State: w.state, Restarts: w.restarts}
+	if w.lastErr != nil {
+		st.LastErr = w.lastErr.Error()
+	}
+	return st
+}
+
+// supervisor 管理后台任务：统一启动、出错重启、停止时取消并等待退出
+type supervisor struct {
+	mu      sync.Mutex
+	workers []*worker
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	wg      sync.WaitGroup
+}
+
+func newSupervisor() *supervisor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &supervisor{ctx: ctx, cancel: cancel}
+}
+
+// Go 注册一个后台任务。HTTP 服务就绪后统一启动；服务已启动时立即启动。
+func (a *App) Go(name string, fn WorkerFunc, opts ...WorkerOption) {
+	w := &worker{
+		name:           name,
+		fn:             fn,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		state:          WorkerPending,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	s := a.workers
+	s.mu.Lock()
+	s.workers = append(s.workers, w)
+	started := s.started
+	s.mu.Unlock()
+	if started {
+		s.run(w)
+	}
+}
+
+// Workers 返回全部后台任务的状态
+func (a *App) Workers() []WorkerStatus {
+	return a.workers.statuses()
+}
+
+func (s *supervisor) statuses() []WorkerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]WorkerStatus, 0, len(s.workers))
+	for _, w := range s.workers {
+		out = append(out, w.status())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// start 启动已注册的全部任务
+func (s *supervisor) start() {
+	s.mu.Lock()
+	s.started = true
+	workers := append([]*worker(nil), s.workers...)
+	s.mu.Unlock()
+	for _, w := range workers {
+		s.run(w)
+	}
+}
+
+// stop 取消全部任务并等待退出
+func (s *supervisor) stop(ctx context.Context) error {
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("workers did not stop in time: %w", ctx.Err())
+	}
+}
+
+func (s *supervisor) run(w *worker) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.loop(w)
+	}()
+}
+
+// loop 运行任务，出错或 panic 后按指数退避重启，直到 ctx 取消
+func (s *supervisor) loop(w *worker) {
+	backoff := w.initialBackoff
+	for {
+		w.setState(WorkerRunning, nil)
+		logger.Infof("worker %s running", w.name)
+
+		started := time.Now()
+		err := runWorker(s.ctx, w)
+		if s.ctx.Err() != nil {
+			w.setState(WorkerFinished, nil)
+			logger.Infof("worker %s stopped", w.name)
+			return
+		}
+		if err == nil {
+			w.setState(WorkerFinished, nil)
+			logger.Infof("worker %s finished", w.name)
+			return
+		}
+
+		// 稳定运行超过退避上限后，认为已恢复，重置退避和计数
+		if time.Since(started) > w.maxBackoff {
+			backoff = w.initialBackoff
+			w.mu.Lock()
+			w.restarts = 0
+			w.mu.Unlock()
+		}
+
+		// 只有确实要重启时才计数，用完次数的那次失败不算
+		w.mu.Lock()
+		if w.maxRestarts > 0 && w.restarts >= w.maxRestarts {
+			restarts := w.restarts
+			w.mu.Unlock()
+			w.setState(WorkerFailed, err)
+			logger.Errorf("worker %s failed after %d restarts: %v", w.name, restarts, err)
+			return
+		}
+		w.restarts++
+		restarts := w.restarts
+		w.mu.Unlock()
+
+		w.setState(WorkerBackoff, err)
+		logger.Warnf("worker %s error: %v, restarting in %s (attempt %d)", w.name, err, backoff, restarts)
+		select {
+		case <-s.ctx.Done():
+			w.setState(WorkerFinished, nil)
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > w.maxBackoff {
+			backoff = w.maxBackoff
+		}
+	}
+}
+
+// runWorker 执行一次任务，panic 转为 error
+func runWorker(ctx context.Context, w *worker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return w.fn(ctx)
+}
+
+// startWorkers HTTP 服务就绪后启动后台任务；停止时在 drain 阶段取消并等待退出
+func startWorkers(app *App) {
+	app.Graceful.Register("workers", graceful.Hook{
+		Phase: graceful.PhaseDrain,
+		Fn:    app.workers.stop,
+	})
+	app.workers.start()
+}
+
+// WorkersChecker 后台任务健康检查：有任务 failed 为 unhealthy，backing off 为 degraded
+func (a *App) WorkersChecker() health.Checker {
+	return health.CheckFunc(func(ctx context.Context) health.CheckResult {
+		res := health.CheckResult{Status: health.StatusHealthy, Details: map[string]interface{}{}}
+		for _, st := range a.Workers() {
+			res.Details[st.Name] = st
+			switch st.State {
+			case WorkerFailed:
+				res.Status = health.StatusUnhealthy
+				res.Message = "worker " + st.Name + " failed: " + st.LastErr
+			case WorkerBackoff:
+				if res.Status == health.StatusHealthy {
+					res.Status = health.StatusDegraded
+					res.Message = "worker " + st.Name + " is restarting"
+				}
+			}
+		}
+		return res
+	})
+}
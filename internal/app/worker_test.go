@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/jiujuan/go-star/pkg/health"
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+func TestWorkers(t *testing.T) {
+	logger.L = logrus.New()
+	logger.L.SetOutput(io.Discard)
+
+	Convey("后台任务测试", t, func() {
+		a := &App{workers: newSupervisor()}
+		fast := WithBackoff(time.Millisecond, 5*time.Millisecond)
+
+		Convey("启动前处于 pending，启动后运行，停止时取消", func() {
+			a.Go("loop", func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+			So(a.Workers()[0].State, ShouldEqual, WorkerPending)
+
+			a.workers.start()
+			So(waitState(a, "loop", WorkerRunning), ShouldBeTrue)
+
+			So(a.workers.stop(context.Background()), ShouldBeNil)
+			So(a.Workers()[0].State, ShouldEqual, WorkerFinished)
+		})
+
+		Convey("出错和 panic 后重启", func() {
+			var calls int32
+			a.Go("flaky", func(ctx context.Context) error {
+				switch atomic.AddInt32(&calls, 1) {
+				case 1:
+					return errors.New("boom")
+				case 2:
+					panic("oops")
+				}
+				<-ctx.Done()
+				return nil
+			}, fast)
+			a.workers.start()
+
+			So(waitState(a, "flaky", WorkerRunning), ShouldBeTrue)
+			So(waitFor(func() bool { return atomic.LoadInt32(&calls) == 3 }), ShouldBeTrue)
+			st := a.Workers()[0]
+			So(st.Restarts, ShouldEqual, 2)
+			So(st.LastErr, ShouldContainSubstring, "oops")
+			So(a.workers.stop(context.Background()), ShouldBeNil)
+		})
+
+		Convey("超过最大重启次数后 failed，健康检查为 unhealthy", func() {
+			var calls int32
+			a.Go("broken", func(ctx context.Context) error {
+				atomic.AddInt32(&calls, 1)
+				return errors.New("boom")
+			}, fast, WithMaxRestarts(2))
+			a.workers.start()
+
+			So(waitState(a, "broken", WorkerFailed), ShouldBeTrue)
+			So(a.Workers()[0].Restarts, ShouldEqual, 2)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 3)
+
+			r := a.WorkersChecker().Check(context.Background())
+			So(r.Status, ShouldEqual, health.StatusUnhealthy)
+			So(r.Message, ShouldContainSubstring, "broken")
+		})
+
+		Convey("启动后注册的任务立即运行", func() {
+			a.workers.start()
+			done := make(chan struct{})
+			a.Go("late", func(ctx context.Context) error {
+				close(done)
+				return nil
+			})
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+			}
+			So(waitState(a, "late", WorkerFinished), ShouldBeTrue)
+			So(a.workers.stop(context.Background()), ShouldBeNil)
+		})
+	})
+}
+
+func waitState(a *App, name string, s WorkerState) bool {
+	return waitFor(func() bool {
+		for _, st := range a.Workers() {
+			if st.Name == name && st.State == s {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func waitFor(cond func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return false
+}
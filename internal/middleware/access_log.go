@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+// AccessLogConfig 访问日志配置，对应 access_log 一节，修改后无需重启
+type AccessLogConfig struct {
+	Enable        bool          `mapstructure:"enable"`
+	SkipPaths     []string      `mapstructure:"skip_paths" comment:"不记录的路径，精确匹配；以 * 结尾时按前缀匹配"`
+	SampleRate    float64       `mapstructure:"sample_rate" validate:"min=0,max=1" comment:"成功请求的采样比例，1 表示全部记录；错误和慢请求总是记录"`
+	SlowThreshold time.Duration `mapstructure:"slow_threshold" validate:"min=0" comment:"耗时超过该值按慢请求记录，0 表示不判断"`
+}
+
+// AccessLogSection access_log 配置节
+var AccessLogSection = config.NewSection("access_log", "访问日志", AccessLogConfig{
+	Enable:        true,
+	SkipPaths:     []string{"/health*", "/metrics", "/ping"},
+	SampleRate:    1,
+	SlowThreshold: time.Second,
+})
+
+// AccessLog 通过 logger.L 输出结构化访问日志：5xx 为 error，4xx 和慢请求为 warn，其余为 info。
+// 需要挂在 RequestID 之后、Recover 之前，才能记录请求 ID 和 panic 后的 500。
+func AccessLog() gin.HandlerFunc {
+	return accessLog(AccessLogSection.Get)
+}
+
+func accessLog(cfg func() AccessLogConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		body := &countingReader{ReadCloser: c.Request.Body}
+		if c.Request.Body != nil {
+			c.Request.Body = body
+		}
+
+		c.Next()
+
+		conf := cfg()
+		if !conf.Enable || skipPath(conf.SkipPaths, c.Request.URL.Path) {
+			return
+		}
+		latency := time.Since(start)
+		status := c.Writer.Status()
+		slow := conf.SlowThreshold > 0 && latency >= conf.SlowThreshold
+		failed := status >= 400 || len(c.Errors) > 0
+		if !failed && !slow && (conf.SampleRate <= 0 || rand.Float64() >= conf.SampleRate) {
+			return
+		}
+
+		bytesIn := c.Request.ContentLength
+		if bytesIn < 0 {
+			bytesIn = body.n
+		}
+		// trace_id、tenant 等请求级字段来自 LogContext
+		entry := logger.NamedFromContext(c.Request.Context(), "http").WithFields(logrus.Fields{
+			"method":     c.Request.Method,
+			"route":      c.FullPath(), // 路由模板，如 /api/v1/users/:id；未匹配时为空
+			"path":       c.Request.URL.Path,
+			"status":     status,
+			"latency_ms": float64(latency.Microseconds()) / 1000,
+			"bytes_in":   bytesIn,
+			"bytes_out":  max(c.Writer.Size(), 0),
+			"client_ip":  c.ClientIP(),
+			"user_agent": c.Request.UserAgent(),
+			"request_id": c.GetString(RequestIDKey),
+			"user_id":    c.GetString(CurrentUserID),
+		})
+		if len(c.Errors) > 0 {
+			entry = entry.WithField("error", c.Errors.String())
+		}
+		if slow {
+			entry = entry.WithField("slow", true)
+		}
+
+		switch {
+		case status >= 500:
+			entry.Error("request")
+		case failed || slow:
+			entry.Warn("request")
+		default:
+			entry.Info("request")
+		}
+	}
+}
+
+// skipPath 路径是否在 skip_paths 中
+func skipPath(patterns []string, path string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// countingReader 统计实际读取的请求体字节数，用于没有 Content-Length 的请求
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+func TestAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	Convey("访问日志", t, func() {
+		var buf bytes.Buffer
+		logger.L = logrus.New()
+		logger.L.SetOutput(&buf)
+		logger.L.SetFormatter(&logrus.JSONFormatter{})
+
+		cfg := AccessLogConfig{Enable: true, SkipPaths: []string{"/health*"}, SampleRate: 1, SlowThreshold: time.Second}
+		r := gin.New()
+		r.Use(RequestID(), accessLog(func() AccessLogConfig { return cfg }), Recover())
+		r.POST("/users/:id", func(c *gin.Context) {
+			c.Set(CurrentUserID, "u1")
+			c.String(http.StatusOK, "hello")
+		})
+		r.GET("/boom", func(c *gin.Context) { panic("boom") })
+		r.GET("/slow", func(c *gin.Context) {
+			time.Sleep(20 * time.Millisecond)
+			c.Status(http.StatusOK)
+		})
+		r.GET("/health/live", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		do := func(method, path, body string) []map[string]interface{} {
+			buf.Reset()
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("User-Agent", "test-agent")
+			req.Header.Set(RequestIDKey, "req-1")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			var entries []map[string]interface{}
+			dec := json.NewDecoder(&buf)
+			for dec.More() {
+				var e map[string]interface{}
+				So(dec.Decode(&e), ShouldBeNil)
+				if e["msg"] == "request" {
+					entries = append(entries, e)
+				}
+			}
+			return entries
+		}
+
+		Convey("记录路由模板、状态、字节数、请求 ID 和用户", func() {
+			entries := do(http.MethodPost, "/users/42", `{"a":1}`)
+			So(entries, ShouldHaveLength, 1)
+			e := entries[0]
+			So(e["level"], ShouldEqual, "info")
+			So(e["method"], ShouldEqual, "POST")
+			So(e["route"], ShouldEqual, "/users/:id")
+			So(e["path"], ShouldEqual, "/users/42")
+			So(e["status"], ShouldEqual, 200)
+			So(e["bytes_in"], ShouldEqual, 7)
+			So(e["bytes_out"], ShouldEqual, 5)
+			So(e["user_agent"], ShouldEqual, "test-agent")
+			So(e["request_id"], ShouldEqual, "req-1")
+			So(e["user_id"], ShouldEqual, "u1")
+			So(e, ShouldContainKey, "latency_ms")
+			So(e, ShouldContainKey, "client_ip")
+		})
+
+		Convey("跳过配置的路径", func() {
+			So(do(http.MethodGet, "/health/live", ""), ShouldBeEmpty)
+		})
+
+		Convey("采样只作用于成功请求，错误和慢请求总是记录", func() {
+			cfg.SampleRate = 0
+			cfg.SlowThreshold = 10 * time.Millisecond
+			So(do(http.MethodPost, "/users/42", ""), ShouldBeEmpty)
+
+			entries := do(http.MethodGet, "/boom", "")
+			So(entries, ShouldHaveLength, 1)
+			So(entries[0]["level"], ShouldEqual, "error")
+			So(entries[0]["status"], ShouldEqual, 500)
+
+			entries = do(http.MethodGet, "/missing", "")
+			So(entries, ShouldHaveLength, 1)
+			So(entries[0]["level"], ShouldEqual, "warning")
+			So(entries[0]["route"], ShouldEqual, "")
+
+			entries = do(http.MethodGet, "/slow", "")
+			So(entries, ShouldHaveLength, 1)
+			So(entries[0]["level"], ShouldEqual, "warning")
+			So(entries[0]["slow"], ShouldEqual, true)
+		})
+
+		Convey("关闭后不记录", func() {
+			cfg.Enable = false
+			So(do(http.MethodGet, "/boom", ""), ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ClientIdentityKey = "client_identity"
+
+// Identity 经过 CA 校验的客户端证书身份
+type Identity struct {
+	CommonName   string   `json:"common_name"`
+	Organization []string `json:"organization,omitempty"`
+	DNSNames     []string `json:"dns_names,omitempty"`
+	Emails       []string `json:"emails,omitempty"`
+	URIs         []string `json:"uris,omitempty"`
+	SerialNumber string   `json:"serial_number"`
+	Issuer       string   `json:"issuer"`
+}
+
+// ClientIdentity 从双向 TLS 连接中取出已校验的客户端证书身份，存入 gin.Context
+func ClientIdentity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tlsState := c.Request.TLS; tlsState != nil && len(tlsState.VerifiedChains) > 0 && len(tlsState.VerifiedChains[0]) > 0 {
+			cert := tlsState.VerifiedChains[0][0]
+			id := &Identity{
+				CommonName:   cert.Subject.CommonName,
+				Organization: cert.Subject.Organization,
+				DNSNames:     cert.DNSNames,
+				Emails:       cert.EmailAddresses,
+				SerialNumber: cert.SerialNumber.String(),
+				Issuer:       cert.Issuer.CommonName,
+			}
+			for _, u := range cert.URIs {
+				id.URIs = append(id.URIs, u.String())
+			}
+			c.Set(ClientIdentityKey, id)
+		}
+		c.Next()
+	}
+}
+
+// RequireClientIdentity 没有已校验的客户端证书时返回 401，需放在 ClientIdentity 之后
+func RequireClientIdentity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetClientIdentity(c); !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"msg": "client certificate required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetClientIdentity 读取 ClientIdentity 中间件存入的身份
+func GetClientIdentity(c *gin.Context) (*Identity, bool) {
+	v, ok := c.Get(ClientIdentityKey)
+	if !ok {
+		return nil, false
+	}
+	id, ok := v.(*Identity)
+	return id, ok
+}
@@ -2,18 +2,44 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/jiujuan/go-star/pkg/config"
+)
+
+// 未配置 server.cors 时的缺省值
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"}
+	defaultCORSHeaders = []string{"Origin", "Content-Type", "Authorization", "X-Request-ID"}
+	defaultCORSMaxAge  = 12 * time.Hour
 )
 
-// CORS 允许跨域请求（按需调整）
+// CORS 允许跨域请求，按 server.cors 配置；每个请求读取最新配置，热更新后立即生效
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,PATCH,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin,Content-Type,Authorization,X-Request-ID")
-		c.Header("Access-Control-Max-Age", fmt.Sprintf("%d", int(12*time.Hour/time.Second)))
+		var cfg config.CORSConfig
+		if cur := config.Current(); cur != nil {
+			cfg = cur.Server.CORS
+		}
+
+		origin := allowOrigin(cfg.AllowOrigins, c.GetHeader("Origin"))
+		if origin != "" {
+			c.Header("Access-Control-Allow-Origin", origin)
+			if origin != "*" {
+				c.Header("Vary", "Origin")
+			}
+			c.Header("Access-Control-Allow-Methods", strings.Join(orDefault(cfg.AllowMethods, defaultCORSMethods), ","))
+			c.Header("Access-Control-Allow-Headers", strings.Join(orDefault(cfg.AllowHeaders, defaultCORSHeaders), ","))
+			maxAge := cfg.MaxAge
+			if maxAge == 0 {
+				maxAge = defaultCORSMaxAge
+			}
+			c.Header("Access-Control-Max-Age", strconv.Itoa(int(maxAge/time.Second)))
+		}
 
 		if c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -21,4 +47,27 @@ func CORS() gin.HandlerFunc {
 		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// allowOrigin 返回 Access-Control-Allow-Origin 的值，来源不被允许时返回空
+func allowOrigin(allowed []string, origin string) string {
+	if len(allowed) == 0 {
+		return "*"
+	}
+	for _, o := range allowed {
+		if o == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+func orDefault(v, def []string) []string {
+	if len(v) == 0 {
+		return def
+	}
+	return v
+}
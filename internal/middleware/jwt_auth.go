@@ -5,7 +5,10 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
 	"github.com/jiujuan/go-star/pkg/jwt"
+	"github.com/jiujuan/go-star/pkg/logger"
 )
 
 const CurrentUserID = "current_user_id"
@@ -27,6 +30,7 @@ func JWT() gin.HandlerFunc {
 		}
 
 		c.Set(CurrentUserID, claims.UserID)
+		SetLogFields(c, logrus.Fields{logger.FieldUserID: claims.UserID})
 		c.Next()
 	}
 }
\ No newline at end of file
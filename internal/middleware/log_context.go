@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+// 链路相关请求头
+const (
+	TraceParentHeader = "traceparent" // W3C Trace Context：00-<trace-id>-<span-id>-<flags>
+	TraceIDHeader     = "X-Trace-ID"
+	TenantHeader      = "X-Tenant-ID"
+)
+
+// LogContext 把请求 ID、trace ID 和租户写入 c.Request.Context()，
+// service、repository 中用 logger.FromContext(ctx) 即可带上这些字段。需放在 RequestID 之后。
+func LogContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fields := logrus.Fields{}
+		if id := c.GetString(RequestIDKey); id != "" {
+			fields[logger.FieldRequestID] = id
+		}
+		if id := traceID(c); id != "" {
+			fields[logger.FieldTraceID] = id
+		}
+		if tenant := c.GetHeader(TenantHeader); tenant != "" {
+			fields[logger.FieldTenant] = tenant
+		}
+		SetLogFields(c, fields)
+		c.Next()
+	}
+}
+
+// SetLogFields 向请求 context 追加日志字段，之后的 handler 通过 c.Request.Context() 取得
+func SetLogFields(c *gin.Context, fields logrus.Fields) {
+	if len(fields) > 0 {
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), fields))
+	}
+}
+
+// traceID 优先取 traceparent 中的 trace-id，其次取 X-Trace-ID
+func traceID(c *gin.Context) string {
+	if parts := strings.Split(c.GetHeader(TraceParentHeader), "-"); len(parts) == 4 && len(parts[1]) == 32 {
+		return parts[1]
+	}
+	return c.GetHeader(TraceIDHeader)
+}
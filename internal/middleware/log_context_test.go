@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+func TestLogContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	Convey("请求级日志字段", t, func() {
+		var buf bytes.Buffer
+		logger.L = logrus.New()
+		logger.L.SetOutput(&buf)
+		logger.L.SetFormatter(&logrus.JSONFormatter{})
+
+		r := gin.New()
+		r.Use(RequestID(), LogContext())
+		r.GET("/work", func(c *gin.Context) {
+			SetLogFields(c, logrus.Fields{logger.FieldUserID: "u1"})
+			// 请求结束后仍在运行的 goroutine 使用 WithoutCancel，字段保持不变
+			ctx := context.WithoutCancel(c.Request.Context())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				logger.FromContext(ctx).Info("async work")
+			}()
+			<-done
+			c.Status(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/work", nil)
+		req.Header.Set(RequestIDKey, "req-1")
+		req.Header.Set(TenantHeader, "acme")
+
+		Convey("request_id、trace_id、tenant 和 user_id 随 ctx 传递", func() {
+			req.Header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			var e map[string]interface{}
+			So(json.Unmarshal(buf.Bytes(), &e), ShouldBeNil)
+			So(e["msg"], ShouldEqual, "async work")
+			So(e[logger.FieldRequestID], ShouldEqual, "req-1")
+			So(e[logger.FieldTraceID], ShouldEqual, "4bf92f3577b34da6a3ce929d0e0e4736")
+			So(e[logger.FieldTenant], ShouldEqual, "acme")
+			So(e[logger.FieldUserID], ShouldEqual, "u1")
+		})
+
+		Convey("没有 traceparent 时使用 X-Trace-ID", func() {
+			req.Header.Set(TraceIDHeader, "trace-1")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			var e map[string]interface{}
+			So(json.Unmarshal(buf.Bytes(), &e), ShouldBeNil)
+			So(e[logger.FieldTraceID], ShouldEqual, "trace-1")
+		})
+
+		Convey("WithContext 合并字段，不影响原 ctx", func() {
+			parent := logger.WithContext(context.Background(), logrus.Fields{"a": 1})
+			child := logger.WithContext(parent, logrus.Fields{"b": 2})
+			So(logger.Fields(child), ShouldResemble, logrus.Fields{"a": 1, "b": 2})
+			So(logger.Fields(parent), ShouldResemble, logrus.Fields{"a": 1})
+		})
+	})
+}
@@ -6,12 +6,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+
+	"github.com/jiujuan/go-star/pkg/logger"
 )
 
-// Recover 捕获 panic，打印堆栈，返回 500
+// Recover 捕获 panic，通过 logger 记录堆栈（经过脱敏），返回 500
 func Recover() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		logrus.WithFields(logrus.Fields{
+		logger.NamedFromContext(c.Request.Context(), "http").WithFields(logrus.Fields{
 			"error": recovered,
 			"stack": string(debug.Stack()),
 		}).Error("panic recovered")
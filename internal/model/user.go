@@ -7,9 +7,10 @@ type User struct {
 	gorm.Model
 	Username string `gorm:"uniqueIndex;size:32"`
 	Password string `gorm:"size:128"` // 已加密
+	IsAdmin  bool   `gorm:"default:false"`
 }
 
 // TableName 显式指定表名
 func (User) TableName() string {
 	return "users"
-}
\ No newline at end of file
+}
@@ -0,0 +1,53 @@
+package module
+
+import (
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+
+	"github.com/jiujuan/go-star/pkg/health"
+)
+
+// Module 一个自包含的业务模块：路由、迁移模型、健康检查和配置缺省值。
+// 配置缺省值属于模块自己的配置节：在模块所在包的包级变量中用 config.NewSection 声明，
+// 包初始化时即已注册，早于加载配置，随整个配置一起校验，参见 router.AuthSection。
+type Module struct {
+	Name   string
+	Prefix string                    // 路由组前缀，如 /api/v1
+	Routes func(g *gin.RouterGroup)  // 在 Prefix 分组下注册路由
+	Models []interface{}             // 需要迁移的模型，按依赖顺序排列
+	Checks map[string]health.Checker // 健康检查，默认按关键检查注册
+}
+
+// Provide 注册一个模块：providers 加入依赖图，constructor 返回 Module 并放入 "modules" 组。
+// 新增业务域只需在 app.Services 里加一行 xxx.Module。
+func Provide(constructor interface{}, providers ...interface{}) fx.Option {
+	return fx.Options(
+		fx.Provide(providers...),
+		fx.Provide(fx.Annotate(constructor, fx.ResultTags(`group:"modules"`))),
+	)
+}
+
+// Params 从 fx 收集全部模块
+type Params struct {
+	fx.In
+
+	Modules []Module `group:"modules"`
+}
+
+// Sorted 按名字排序的模块列表，fx 组内顺序不固定
+func (p Params) Sorted() []Module {
+	mods := append([]Module(nil), p.Modules...)
+	sort.Slice(mods, func(i, j int) bool { return mods[i].Name < mods[j].Name })
+	return mods
+}
+
+// Models 汇总全部模块的迁移模型
+func (p Params) Models() []interface{} {
+	var models []interface{}
+	for _, m := range p.Sorted() {
+		models = append(models, m.Models...)
+	}
+	return models
+}
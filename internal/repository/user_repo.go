@@ -5,6 +5,7 @@ import (
 
 	"github.com/jiujuan/go-star/internal/model"
 	"github.com/jiujuan/go-star/pkg/db"
+	"github.com/jiujuan/go-star/pkg/logger"
 	"gorm.io/gorm"
 )
 
@@ -19,6 +20,7 @@ func NewUserRepo(d *db.DB) *UserRepo {
 // Create 插入一条用户记录
 func (r *UserRepo) Create(ctx context.Context, u *model.User) (*model.User, error) {
 	if err := r.db.WithContext(ctx).Create(u).Error; err != nil {
+		logger.NamedFromContext(ctx, "service").WithError(err).WithField("username", u.Username).Error("create user failed")
 		return nil, err
 	}
 	return u, nil
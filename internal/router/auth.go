@@ -0,0 +1,58 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jiujuan/go-star/internal/handler"
+	"github.com/jiujuan/go-star/internal/middleware"
+	"github.com/jiujuan/go-star/internal/model"
+	"github.com/jiujuan/go-star/internal/module"
+	"github.com/jiujuan/go-star/internal/repository"
+	"github.com/jiujuan/go-star/internal/service"
+	"github.com/jiujuan/go-star/pkg/config"
+)
+
+// AuthConfig auth 模块的配置，对应 auth 一节
+type AuthConfig struct {
+	Register bool `mapstructure:"register" comment:"是否开放注册，修改后无需重启"`
+}
+
+// AuthSection auth 模块的配置缺省值
+var AuthSection = config.NewSection("auth", "注册、登录和当前用户", AuthConfig{Register: true})
+
+// Auth 注册、登录和当前用户模块
+var Auth = module.Provide(
+	newAuthModule,
+	repository.NewUserRepo,
+	service.NewUserService,
+	handler.NewAuthHandler,
+)
+
+func newAuthModule(h *handler.AuthHandler) module.Module {
+	return module.Module{
+		Name:   "auth",
+		Prefix: "/api/v1",
+		Routes: func(g *gin.RouterGroup) {
+			auth := g.Group("/auth")
+			{
+				auth.POST("/register", registerOpen, h.Register)
+				auth.POST("/login", h.Login)
+			}
+			user := g.Group("/users")
+			user.Use(middleware.JWT())
+			{
+				user.GET("/me", h.Me)
+			}
+		},
+		Models: []interface{}{&model.User{}},
+	}
+}
+
+// registerOpen auth.register 为 false 时关闭注册
+func registerOpen(c *gin.Context) {
+	if !AuthSection.Get().Register {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"msg": "registration is closed"})
+	}
+}
@@ -2,32 +2,26 @@ package router
 
 import (
 	"github.com/gin-gonic/gin"
-	"github.com/jiujuan/go-star/internal/handler"
-	"github.com/jiujuan/go-star/internal/middleware"
+	"github.com/jiujuan/go-star/internal/module"
+	"go.uber.org/fx"
 )
 
 type Router struct {
-	Auth *handler.AuthHandler
+	modules []module.Module
 }
 
-func NewRouter(auth *handler.AuthHandler) *Router {
-	return &Router{Auth: auth}
+func NewRouter(p module.Params) *Router {
+	return &Router{modules: p.Sorted()}
 }
 
+// Register 把每个模块的路由挂到各自的前缀分组下；全局中间件已由 app.NewApp 挂载
 func (r *Router) Register(app *gin.Engine) {
-	app.Use(middleware.RequestID(), middleware.Recover(), middleware.CORS())
-
-	api := app.Group("/api/v1")
-	{
-		auth := api.Group("/auth")
-		{
-			auth.POST("/register", r.Auth.Register)
-			auth.POST("/login", r.Auth.Login)
-		}
-		user := api.Group("/users")
-		user.Use(middleware.JWT())
-		{
-			user.GET("/me", r.Auth.Me)
+	for _, m := range r.modules {
+		if m.Routes != nil {
+			m.Routes(app.Group(m.Prefix))
 		}
 	}
-}
\ No newline at end of file
+}
+
+// Module 把路由挂载到 App 的 gin 引擎上
+var Module = fx.Invoke(func(r *Router, engine *gin.Engine) { r.Register(engine) })
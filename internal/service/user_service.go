@@ -2,12 +2,13 @@ package service
 
 import (
 	"context"
-	"time"
+	"fmt"
 
 	"github.com/jiujuan/go-star/internal/model"
 	"github.com/jiujuan/go-star/internal/repository"
 	"github.com/jiujuan/go-star/pkg/cache"
 	"github.com/jiujuan/go-star/pkg/jwt"
+	"github.com/jiujuan/go-star/pkg/logger"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -24,7 +25,32 @@ func NewUserService(repo *repository.UserRepo, cache *cache.Cache, j *jwt.Manage
 func (s *UserService) Register(ctx context.Context, username, password string) (*model.User, error) {
 	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	u := &model.User{Username: username, Password: string(hash)}
-	return s.repo.Create(ctx, u)
+	if _, err := s.repo.Create(ctx, u); err != nil {
+		return nil, err
+	}
+	logger.NamedFromContext(ctx, "service").WithField("username", username).Info("user registered")
+	return u, nil
+}
+
+// CreateAdmin 创建管理员账号，用户名已存在时报错
+func (s *UserService) CreateAdmin(ctx context.Context, username, password string) (*model.User, error) {
+	exist, err := s.repo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if exist != nil {
+		return nil, fmt.Errorf("user %q already exists", username)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	u := &model.User{Username: username, Password: string(hash), IsAdmin: true}
+	if _, err := s.repo.Create(ctx, u); err != nil {
+		return nil, err
+	}
+	logger.NamedFromContext(ctx, "service").WithField("username", username).Info("admin user created")
+	return u, nil
 }
 
 func (s *UserService) Login(ctx context.Context, username, password string) (string, error) {
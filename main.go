@@ -2,16 +2,15 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	
-	"go-star/pkg/graceful"
-	"go-star/pkg/health"
+
+	"github.com/jiujuan/go-star/pkg/graceful"
+	"github.com/jiujuan/go-star/pkg/health"
 )
 
 func main() {
@@ -37,7 +36,7 @@ func main() {
 
 	// TODO: Add database and Redis health checks when those components are available
 	// healthManager.Register("database", health.NewDatabaseChecker("database", db.Ping))
-	// healthManager.Register("redis", health.NewRedisChecker("redis", redisClient.Ping))
+	// healthManager.Register("redis", health.NewRedisChecker("redis", func(ctx context.Context) error { return redisClient.Ping(ctx).Err() }))
 
 	// Create graceful shutdown manager
 	shutdownManager := graceful.NewManager()
@@ -89,6 +88,9 @@ func main() {
 		MaxHeaderBytes: 1 << 20, // 1MB
 	}
 
+	// Fail readiness first so load balancers stop routing traffic
+	shutdownManager.Register("health-readiness", healthManager.ShutdownHook())
+
 	// Register HTTP server for graceful shutdown
 	shutdownManager.Register("http-server", graceful.HTTPServerShutdown(srv))
 
@@ -106,7 +108,7 @@ func main() {
 		log.Printf("  - Health: http://localhost:%s/health", port)
 		log.Printf("  - Ready:  http://localhost:%s/health/ready", port)
 		log.Printf("  - Live:   http://localhost:%s/health/live", port)
-		
+
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
 		}
@@ -116,4 +118,4 @@ func main() {
 	log.Println("Server started successfully. Press Ctrl+C to shutdown.")
 	shutdownManager.Wait()
 	log.Println("Server shutdown completed.")
-}
\ No newline at end of file
+}
@@ -1,17 +1,51 @@
 package cache
 
 import (
+	"sync/atomic"
 	"time"
 
 	gocache "github.com/patrickmn/go-cache"
+	"go.uber.org/fx"
+
+	"github.com/jiujuan/go-star/pkg/config"
 )
 
+// Config 本地缓存配置，对应 cache 一节
+type Config struct {
+	DefaultExpiration time.Duration `mapstructure:"default_expiration" validate:"min=0" comment:"SetDefault 使用的过期时间，修改后无需重启"`
+	CleanupInterval   time.Duration `mapstructure:"cleanup_interval" validate:"min=0" comment:"过期清理间隔，仅启动时生效"`
+}
+
+// Section cache 配置节
+var Section = config.NewSection("cache", "本地缓存", Config{DefaultExpiration: 5 * time.Minute, CleanupInterval: 10 * time.Minute})
+
 type Cache struct {
 	*gocache.Cache
+	expire atomic.Int64 // 当前缺省过期时间
+}
+
+func New(cfg Config) *Cache {
+	c := &Cache{Cache: gocache.New(cfg.DefaultExpiration, cfg.CleanupInterval)}
+	c.expire.Store(int64(cfg.DefaultExpiration))
+	Section.Subscribe(func(old, new Config) {
+		if new.DefaultExpiration > 0 {
+			c.expire.Store(int64(new.DefaultExpiration))
+		}
+	})
+	return c
+}
+
+// Set 写入缓存，d 为 gocache.DefaultExpiration 时使用配置中的缺省过期时间
+func (c *Cache) Set(k string, x interface{}, d time.Duration) {
+	if d == gocache.DefaultExpiration {
+		d = time.Duration(c.expire.Load())
+	}
+	c.Cache.Set(k, x, d)
 }
 
-func New() *Cache {
-	return &Cache{gocache.New(5*time.Minute, 10*time.Minute)}
+// SetDefault 使用配置中的缺省过期时间写入
+func (c *Cache) SetDefault(k string, x interface{}) {
+	c.Set(k, x, gocache.DefaultExpiration)
 }
 
-var Module = fx.Provide(New)
\ No newline at end of file
+var Module = fx.Options(Section.Provide(), fx.Provide(New))
@@ -0,0 +1,193 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+// DefaultReloadInterval 证书文件检查间隔
+const DefaultReloadInterval = 10 * time.Second
+
+// Reloader 从磁盘加载证书，文件变化后自动重新加载，无需重启。
+// 重新加载失败时保留上一份可用证书。
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime map[string]time.Time
+}
+
+// NewReloader 加载证书；caFile 为空表示不校验客户端证书
+func NewReloader(certFile, keyFile, caFile string) (*Reloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("certs: cert_file and key_file are required")
+	}
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		modTime:  make(map[string]time.Time),
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload 重新读取证书、私钥和 CA
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("certs: load key pair: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("certs: read client ca: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("certs: no certificate found in %s", r.caFile)
+		}
+	}
+
+	modTime := make(map[string]time.Time, 3)
+	for _, f := range r.files() {
+		if fi, err := os.Stat(f); err == nil {
+			modTime[f] = fi.ModTime()
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.pool, r.modTime = &cert, pool, modTime
+	r.mu.Unlock()
+	return nil
+}
+
+// Watch 定期检查文件修改时间，变化时重新加载，直到 ctx 结束
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				logger.Errorf("tls certificate reload failed, keep the previous one: %v", err)
+				continue
+			}
+			logger.Infof("tls certificate reloaded from %s", r.certFile)
+		}
+	}
+}
+
+// changed 任一文件的修改时间发生变化
+func (r *Reloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, f := range r.files() {
+		fi, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if !fi.ModTime().Equal(r.modTime[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	return files
+}
+
+// GetCertificate 供 tls.Config 使用，始终返回最新证书
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// ClientCAs 当前的客户端 CA
+func (r *Reloader) ClientCAs() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pool
+}
+
+// TLSConfig 生成服务端配置；每次握手都使用最新的证书和客户端 CA。
+// 校验客户端证书的模式必须配置客户端 CA，否则任何客户端证书都无法通过校验。
+func (r *Reloader) TLSConfig(clientAuth tls.ClientAuthType, minVersion uint16) (*tls.Config, error) {
+	if clientAuth >= tls.VerifyClientCertIfGiven && r.caFile == "" {
+		return nil, fmt.Errorf("certs: client_auth %s requires a client CA file", clientAuth)
+	}
+	base := &tls.Config{
+		MinVersion:     minVersion,
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: r.GetCertificate,
+		ClientAuth:     clientAuth,
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := base.Clone()
+		c.GetConfigForClient = nil
+		c.ClientCAs = r.ClientCAs()
+		return c, nil
+	}
+	return base, nil
+}
+
+// ParseClientAuth 解析配置中的客户端认证模式
+func ParseClientAuth(s string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require_and_verify":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("certs: unknown client_auth %q", s)
+	}
+}
+
+// ParseVersion 解析 TLS 版本，默认 1.2
+func ParseVersion(s string) (uint16, error) {
+	switch s {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("certs: unsupported min_version %q", s)
+	}
+}
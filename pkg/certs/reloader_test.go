@@ -0,0 +1,100 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// writeSelfSigned 生成自签名证书写入 dir，返回证书和私钥路径
+func writeSelfSigned(dir, cn string) (string, string) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{cn},
+	}
+	der, _ := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func leafCN(r *Reloader) string {
+	c, _ := r.GetCertificate(nil)
+	leaf, _ := x509.ParseCertificate(c.Certificate[0])
+	return leaf.Subject.CommonName
+}
+
+func TestReloader(t *testing.T) {
+	Convey("证书热加载测试", t, func() {
+		dir := t.TempDir()
+		certFile, keyFile := writeSelfSigned(dir, "v1.local")
+
+		r, err := NewReloader(certFile, keyFile, "")
+		So(err, ShouldBeNil)
+		So(leafCN(r), ShouldEqual, "v1.local")
+		So(r.changed(), ShouldBeFalse)
+
+		Convey("文件变化后重新加载", func() {
+			writeSelfSigned(dir, "v2.local")
+			future := time.Now().Add(time.Minute)
+			_ = os.Chtimes(certFile, future, future)
+
+			So(r.changed(), ShouldBeTrue)
+			So(r.Reload(), ShouldBeNil)
+			So(leafCN(r), ShouldEqual, "v2.local")
+		})
+
+		Convey("新证书无效时保留旧证书", func() {
+			_ = os.WriteFile(certFile, []byte("broken"), 0o600)
+			So(r.Reload(), ShouldNotBeNil)
+			So(leafCN(r), ShouldEqual, "v1.local")
+		})
+
+		Convey("配置 CA 后每次握手使用最新的客户端 CA", func() {
+			r2, err := NewReloader(certFile, keyFile, certFile)
+			So(err, ShouldBeNil)
+			cfg, err := r2.TLSConfig(tls.RequireAndVerifyClientCert, tls.VersionTLS12)
+			So(err, ShouldBeNil)
+			c, err := cfg.GetConfigForClient(nil)
+			So(err, ShouldBeNil)
+			So(c.ClientCAs, ShouldNotBeNil)
+			So(c.ClientAuth, ShouldEqual, tls.RequireAndVerifyClientCert)
+		})
+
+		Convey("校验客户端证书的模式没有 CA 时报错", func() {
+			for _, mode := range []tls.ClientAuthType{tls.VerifyClientCertIfGiven, tls.RequireAndVerifyClientCert} {
+				_, err := r.TLSConfig(mode, tls.VersionTLS12)
+				So(err, ShouldNotBeNil)
+			}
+			_, err := r.TLSConfig(tls.RequireAnyClientCert, tls.VersionTLS12)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("解析配置项", func() {
+			_, err := ParseClientAuth("bogus")
+			So(err, ShouldNotBeNil)
+			v, err := ParseVersion("1.3")
+			So(err, ShouldBeNil)
+			So(v, ShouldEqual, tls.VersionTLS13)
+		})
+	})
+}
@@ -0,0 +1,676 @@
+package config_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/jiujuan/go-star/pkg/config"
+)
+
+const baseYAML = `
+server:
+  port: 8080
+  mode: debug
+  read_timeout: 30s
+mysql:
+  dsn: "user:pass@tcp(127.0.0.1:3306)/base"
+  max_open_conns: 50
+  max_lifetime: 1h
+  slow_threshold: 500ms
+redis:
+  addr: "127.0.0.1:6379"
+jwt:
+  secret: "from-base"
+  expire: 24h
+log:
+  level: info
+plugins:
+  metrics:
+    enabled: true
+`
+
+const devYAML = `
+server:
+  mode: release
+log:
+  level: debug
+`
+
+// 与 db、redis、jwt 包中的配置节相同，config 包的测试不依赖这些包
+type mysqlConfig struct {
+	DSN           string        `mapstructure:"dsn" validate:"required"`
+	Password      string        `mapstructure:"password"`
+	MaxOpen       int           `mapstructure:"max_open_conns" validate:"min=0"`
+	MaxLifetime   time.Duration `mapstructure:"max_lifetime" validate:"min=0"`
+	SlowThreshold time.Duration `mapstructure:"slow_threshold" validate:"min=0"`
+}
+
+type redisConfig struct {
+	Addr     string `mapstructure:"addr" validate:"required"`
+	Password string `mapstructure:"password"`
+}
+
+type jwtConfig struct {
+	Secret string        `mapstructure:"secret" validate:"required"`
+	Expire time.Duration `mapstructure:"expire" validate:"gt=0"`
+}
+
+var (
+	mysqlSection = config.NewSection("mysql", "", mysqlConfig{MaxOpen: 50})
+	redisSection = config.NewSection("redis", "", redisConfig{})
+	jwtSection   = config.NewSection("jwt", "", jwtConfig{Expire: 24 * time.Hour})
+)
+
+type lateConfig struct {
+	TokenTTL time.Duration `mapstructure:"token_ttl"`
+}
+
+// lateSections 加载后才注册的配置节个数，-count 多次运行时 key 不重复
+var lateSections int
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// unsetenv 删除环境变量，测试结束后恢复
+func unsetenv(t *testing.T, keys ...string) {
+	for _, k := range keys {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	Convey("分层加载配置", t, func() {
+		dir := t.TempDir()
+		writeFile(t, dir, "app.yaml", baseYAML)
+		writeFile(t, dir, "app.dev.yaml", devYAML)
+		t.Setenv(config.EnvFile, filepath.Join(dir, "missing.env"))
+		// Convey 每个分支都会重新执行外层，清掉其他分支设置的变量
+		unsetenv(t, config.EnvProfile, "GOSTAR_SERVER_PORT", "GOSTAR_MYSQL_MAX_OPEN_CONNS",
+			"GOSTAR_SERVER_READ_HEADER_TIMEOUT", "GOSTAR_PLUGINS__AUDIT__ENABLED", "GOSTAR_PLUGINS_METRICS_ENABLED")
+
+		Convey("只有基础配置", func() {
+			c, err := config.Load(dir)
+			So(err, ShouldBeNil)
+			So(c.Server.Mode, ShouldEqual, "debug")
+			So(c.Server.ReadTimeout, ShouldEqual, 30*time.Second)
+		})
+
+		Convey("GOSTAR_ENV 选择的环境配置覆盖基础配置", func() {
+			t.Setenv(config.EnvProfile, "dev")
+			c, err := config.Load(dir)
+			So(err, ShouldBeNil)
+			So(c.Server.Mode, ShouldEqual, "release")
+			So(c.Log.Level, ShouldEqual, "debug")
+			So(c.Server.Port, ShouldEqual, 8080)
+		})
+
+		Convey("环境配置文件不存在时忽略", func() {
+			t.Setenv(config.EnvProfile, "staging")
+			c, err := config.Load(dir)
+			So(err, ShouldBeNil)
+			So(c.Server.Mode, ShouldEqual, "debug")
+		})
+
+		Convey("环境变量覆盖文件，进程环境变量优先于 .env", func() {
+			t.Setenv(config.EnvFile, writeFile(t, dir, ".env", `
+# comment
+GOSTAR_ENV=dev
+export GOSTAR_JWT_SECRET="from-dotenv"
+GOSTAR_MYSQL_DSN='user:pass@tcp(db:3306)/dotenv'
+GOSTAR_SERVER_PORT=9000 # inline comment
+`))
+			t.Setenv("GOSTAR_SERVER_PORT", "9100")
+			t.Setenv("GOSTAR_MYSQL_MAX_OPEN_CONNS", "10")
+			t.Setenv("GOSTAR_SERVER_READ_HEADER_TIMEOUT", "5s")
+
+			c, err := config.Load(dir)
+			So(err, ShouldBeNil)
+			So(c.Log.Level, ShouldEqual, "debug")
+			So(jwtSection.Get().Secret, ShouldEqual, "from-dotenv")
+			So(mysqlSection.Get().DSN, ShouldEqual, "user:pass@tcp(db:3306)/dotenv")
+			So(c.Server.Port, ShouldEqual, 9100)
+			So(mysqlSection.Get().MaxOpen, ShouldEqual, 10)
+			// 文件中没有、结构体中有的 key
+			So(c.Server.ReadHeaderTimeout, ShouldEqual, 5*time.Second)
+		})
+
+		Convey("__ 显式分隔层级", func() {
+			t.Setenv("GOSTAR_PLUGINS__AUDIT__ENABLED", "false")
+			t.Setenv("GOSTAR_PLUGINS_METRICS_ENABLED", "false")
+			_, err := config.Load(dir)
+			So(err, ShouldBeNil)
+
+			var audit, metrics struct{ Enabled bool }
+			So(config.UnmarshalKey("plugins.audit", &audit), ShouldBeNil)
+			So(config.UnmarshalKey("plugins.metrics", &metrics), ShouldBeNil)
+			So(audit.Enabled, ShouldBeFalse)
+			So(metrics.Enabled, ShouldBeFalse)
+		})
+
+		Convey(".env 格式错误时报错", func() {
+			t.Setenv(config.EnvFile, writeFile(t, dir, "bad.env", "GOSTAR_JWT_SECRET\n"))
+			_, err := config.Load(dir)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestValidate(t *testing.T) {
+	Convey("配置校验", t, func() {
+		dir := t.TempDir()
+		t.Setenv(config.EnvFile, filepath.Join(dir, "missing.env"))
+		unsetenv(t, config.EnvProfile)
+
+		Convey("时长字段解析为 time.Duration", func() {
+			writeFile(t, dir, "app.yaml", baseYAML)
+			_, err := config.Load(dir)
+			So(err, ShouldBeNil)
+			So(mysqlSection.Get().MaxLifetime, ShouldEqual, time.Hour)
+			So(mysqlSection.Get().SlowThreshold, ShouldEqual, 500*time.Millisecond)
+			So(jwtSection.Get().Expire, ShouldEqual, 24*time.Hour)
+		})
+
+		Convey("全部问题汇总为一个错误", func() {
+			writeFile(t, dir, "app.yaml", `
+server:
+  port: 70000
+  mode: prod
+mysql:
+  max_lifetime: 3600
+  slow_threshold: soon
+jwt:
+  expire: 0
+log:
+  level: verbose
+`)
+			_, err := config.Load(dir)
+			So(err, ShouldNotBeNil)
+
+			var ce *config.Error
+			So(errors.As(err, &ce), ShouldBeTrue)
+			msg := err.Error()
+			for _, key := range []string{
+				"server.port", "server.mode", "mysql.dsn", "mysql.max_lifetime", "mysql.slow_threshold",
+				"redis.addr", "jwt.secret", "jwt.expire", "log.level",
+			} {
+				So(msg, ShouldContainSubstring, key)
+			}
+			So(msg, ShouldContainSubstring, `has no unit`)
+			So(msg, ShouldContainSubstring, `"soon"`)
+			So(len(ce.Problems), ShouldEqual, 9)
+		})
+
+		Convey("校验客户端证书时必须配置客户端 CA", func() {
+			for _, mode := range []string{"verify_if_given", "require_and_verify"} {
+				writeFile(t, dir, "app.yaml", strings.Replace(baseYAML, "server:\n", "server:\n  tls:\n    client_auth: "+mode+"\n", 1))
+				_, err := config.Load(dir)
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "server.tls.client_ca_file is required")
+			}
+
+			writeFile(t, dir, "app.yaml", strings.Replace(baseYAML, "server:\n", "server:\n  tls:\n    client_auth: require\n", 1))
+			_, err := config.Load(dir)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("环境变量中的时长同样校验", func() {
+			writeFile(t, dir, "app.yaml", baseYAML)
+			t.Setenv("GOSTAR_JWT_EXPIRE", "90")
+			_, err := config.Load(dir)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "jwt.expire")
+		})
+	})
+}
+
+func TestReload(t *testing.T) {
+	Convey("配置热更新", t, func() {
+		dir := t.TempDir()
+		t.Setenv(config.EnvFile, filepath.Join(dir, "missing.env"))
+		unsetenv(t, config.EnvProfile)
+		writeFile(t, dir, "app.yaml", baseYAML)
+		boot, err := config.Load(dir)
+		So(err, ShouldBeNil)
+
+		var calls []string
+		unsubscribe := config.Subscribe("log", func(old, new config.LogConfig) {
+			calls = append(calls, old.Level+"->"+new.Level)
+		})
+		defer unsubscribe()
+
+		Convey("文件变化后替换快照并通知订阅者", func() {
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+
+			writeFile(t, dir, "app.yaml", strings.Replace(baseYAML, "level: info", "level: warn", 1))
+			So(config.Reload(), ShouldBeNil)
+			So(buf.String(), ShouldContainSubstring, "log.level changed: info -> warn")
+			So(config.Current().Log.Level, ShouldEqual, "warn")
+			So(calls, ShouldResemble, []string{"info->warn"})
+			// 启动时的 C 不变
+			So(config.C, ShouldEqual, boot)
+			So(boot.Log.Level, ShouldEqual, "info")
+		})
+
+		Convey("订阅的节未变化时不通知", func() {
+			writeFile(t, dir, "app.yaml", strings.Replace(baseYAML, "port: 8080", "port: 8081", 1))
+			So(config.Reload(), ShouldBeNil)
+			So(config.Current().Server.Port, ShouldEqual, 8081)
+			So(calls, ShouldBeEmpty)
+		})
+
+		Convey("无效配置被拒绝，保留上一份", func() {
+			writeFile(t, dir, "app.yaml", strings.Replace(baseYAML, "level: info", "level: loud", 1))
+			So(config.Reload(), ShouldNotBeNil)
+			So(config.Current().Log.Level, ShouldEqual, "info")
+			So(calls, ShouldBeEmpty)
+		})
+
+		Convey("Watch 监听文件变化自动加载", func() {
+			stop := config.Watch()
+			defer stop()
+
+			writeFile(t, dir, "app.yaml", strings.Replace(baseYAML, "level: info", "level: error", 1))
+			deadline := time.Now().Add(3 * time.Second)
+			for time.Now().Before(deadline) && config.Current().Log.Level != "error" {
+				time.Sleep(20 * time.Millisecond)
+			}
+			So(config.Current().Log.Level, ShouldEqual, "error")
+		})
+
+		Convey("之后新建的环境配置文件同样生效", func() {
+			t.Setenv(config.EnvProfile, "dev")
+			stop := config.Watch()
+			defer stop()
+
+			writeFile(t, dir, "app.dev.yaml", devYAML)
+			deadline := time.Now().Add(3 * time.Second)
+			for time.Now().Before(deadline) && config.Current().Log.Level != "debug" {
+				time.Sleep(20 * time.Millisecond)
+			}
+			So(config.Current().Log.Level, ShouldEqual, "debug")
+		})
+
+		Convey("停止后不再占用 goroutine", func() {
+			n := runtime.NumGoroutine()
+			for i := 0; i < 10; i++ {
+				config.Watch()()
+			}
+			So(runtime.NumGoroutine(), ShouldBeLessThanOrEqualTo, n)
+		})
+	})
+}
+
+func TestSecrets(t *testing.T) {
+	Convey("值引用与加密", t, func() {
+		dir := t.TempDir()
+		t.Setenv(config.EnvFile, filepath.Join(dir, "missing.env"))
+		unsetenv(t, config.EnvProfile, config.EnvMasterKey, "JWT_SECRET_SOURCE")
+
+		key, err := config.GenerateKey()
+		So(err, ShouldBeNil)
+		raw, err := base64.StdEncoding.DecodeString(key)
+		So(err, ShouldBeNil)
+
+		Convey("加密后可解密，密钥错误时失败", func() {
+			enc, err := config.Encrypt("s3cret", raw)
+			So(err, ShouldBeNil)
+			So(enc, ShouldStartWith, "enc:")
+
+			plain, err := config.Decrypt(enc, raw)
+			So(err, ShouldBeNil)
+			So(plain, ShouldEqual, "s3cret")
+
+			other := make([]byte, 32)
+			_, err = config.Decrypt(enc, other)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("解析 file:/env:/enc: 并在输出时脱敏", func() {
+			secretFile := writeFile(t, dir, "redis_password", "from-file\n")
+			enc, err := config.Encrypt("db-pass", raw)
+			So(err, ShouldBeNil)
+			t.Setenv(config.EnvMasterKey, key)
+			t.Setenv("JWT_SECRET_SOURCE", "from-env")
+
+			yaml := strings.NewReplacer(
+				`secret: "from-base"`, `secret: "env:JWT_SECRET_SOURCE"`,
+				`addr: "127.0.0.1:6379"`, "addr: \"127.0.0.1:6379\"\n  password: \"file:"+secretFile+"\"",
+				`max_open_conns: 50`, "max_open_conns: 50\n  password: \""+enc+"\"",
+				`level: info`, `level: "env:JWT_SECRET_SOURCE_LEVEL"`,
+			).Replace(baseYAML)
+			t.Setenv("JWT_SECRET_SOURCE_LEVEL", "warn")
+			writeFile(t, dir, "app.yaml", yaml)
+
+			c, err := config.Load(dir)
+			So(err, ShouldBeNil)
+			So(jwtSection.Get().Secret, ShouldEqual, "from-env")
+			So(redisSection.Get().Password, ShouldEqual, "from-file")
+			So(mysqlSection.Get().Password, ShouldEqual, "db-pass")
+			So(c.Log.Level, ShouldEqual, "warn")
+
+			dump := config.Dump()
+			So(dump["redis"].(map[string]interface{})["password"], ShouldEqual, config.Mask)
+			So(dump["mysql"].(map[string]interface{})["password"], ShouldEqual, config.Mask)
+			// 不属于敏感 key 的字段，来自引用时同样脱敏
+			So(dump["log"].(map[string]interface{})["level"], ShouldEqual, config.Mask)
+		})
+
+		Convey("DSN 只遮盖密码，密码可以包含 @", func() {
+			for dsn, want := range map[string]string{
+				"user:pass@tcp(127.0.0.1:3306)/db":                "user:" + config.Mask + "@tcp(127.0.0.1:3306)/db",
+				"user:p@ss:w@rd@tcp(127.0.0.1:3306)/db?loc=Local": "user:" + config.Mask + "@tcp(127.0.0.1:3306)/db?loc=Local",
+				"user:p@ss/x@unix(/tmp/mysql.sock)/db":            "user:" + config.Mask + "@unix(/tmp/mysql.sock)/db",
+				"redis://user:p@ss@127.0.0.1:6379/0":              "redis://user:" + config.Mask + "@127.0.0.1:6379/0",
+				"postgres://user:p@ss@db.local":                   "postgres://user:" + config.Mask + "@db.local",
+				"user@tcp(127.0.0.1:3306)/db":                     "user@tcp(127.0.0.1:3306)/db",
+				"/db":                                             "/db",
+			} {
+				So(config.Redact("mysql.dsn", dsn), ShouldEqual, want)
+			}
+		})
+
+		Convey("引用无法解析时汇总报错", func() {
+			yaml := strings.NewReplacer(
+				`secret: "from-base"`, `secret: "env:NOT_SET_ANYWHERE"`,
+				`addr: "127.0.0.1:6379"`, "addr: \"127.0.0.1:6379\"\n  password: \"file:"+filepath.Join(dir, "missing")+"\"",
+				`max_open_conns: 50`, "max_open_conns: 50\n  password: \"enc:AAAA\"",
+			).Replace(baseYAML)
+			writeFile(t, dir, "app.yaml", yaml)
+
+			_, err := config.Load(dir)
+			So(err, ShouldNotBeNil)
+			msg := err.Error()
+			So(msg, ShouldContainSubstring, "jwt.secret")
+			So(msg, ShouldContainSubstring, "redis.password")
+			So(msg, ShouldContainSubstring, "mysql.password: "+config.EnvMasterKey+" is not set")
+		})
+	})
+}
+
+func TestSources(t *testing.T) {
+	Convey("配置来源", t, func() {
+		dir := t.TempDir()
+		unsetenv(t, config.EnvMasterKey, "GOSTAR_SERVER_PORT", "JWT_SECRET_SOURCE")
+		base := writeFile(t, dir, "app.yaml", strings.Replace(baseYAML, `secret: "from-base"`, `secret: "env:JWT_SECRET_SOURCE"`, 1))
+		profile := writeFile(t, dir, "app.dev.yaml", devYAML)
+		dotenv := writeFile(t, dir, ".env", "GOSTAR_ENV=dev\nGOSTAR_MYSQL_MAX_OPEN_CONNS=10\n")
+		t.Setenv(config.EnvFile, dotenv)
+		t.Setenv("GOSTAR_SERVER_PORT", "9000")
+		t.Setenv("JWT_SECRET_SOURCE", "from-env")
+
+		_, err := config.Load(dir)
+		So(err, ShouldBeNil)
+		// 加载后注册的配置节发布新快照，并发读取的旧快照不受影响（配合 -race）
+		lateSections++
+		key := fmt.Sprintf("late%d", lateSections)
+		before := config.Current()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				_ = config.IsSet(key + ".token_ttl")
+				_ = config.Settings()
+			}
+		}()
+		late := config.NewSection(key, "", lateConfig{TokenTTL: time.Hour})
+		<-done
+		So(config.Current() != before, ShouldBeTrue)
+		So(config.C == before, ShouldBeTrue)
+		So(late.Get().TokenTTL, ShouldEqual, time.Hour)
+
+		src := config.Sources()
+		So(src["server.read_timeout"], ShouldResemble, config.Source{Layer: config.LayerFile, Origin: base})
+		So(src["server.mode"], ShouldResemble, config.Source{Layer: config.LayerProfile, Origin: profile})
+		So(src["mysql.max_open_conns"], ShouldResemble, config.Source{Layer: config.LayerDotenv, Origin: dotenv + ":GOSTAR_MYSQL_MAX_OPEN_CONNS"})
+		So(src["server.port"], ShouldResemble, config.Source{Layer: config.LayerEnv, Origin: "GOSTAR_SERVER_PORT"})
+		So(src["jwt.secret"].Ref, ShouldEqual, "env:JWT_SECRET_SOURCE")
+		So(src[key+".token_ttl"].Layer, ShouldEqual, config.LayerDefault)
+
+		var secret config.Setting
+		for _, s := range config.Settings() {
+			if s.Key == "jwt.secret" {
+				secret = s
+			}
+		}
+		So(secret.Value, ShouldEqual, config.Mask)
+		So(secret.Source.Layer, ShouldEqual, config.LayerFile)
+	})
+}
+
+// kvServer 模拟远程配置服务，按 ETag 返回 304
+type kvServer struct {
+	mu       sync.Mutex
+	doc      string
+	version  int
+	requests int
+}
+
+func (s *kvServer) set(doc string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.doc = doc
+	s.version++
+}
+
+func (s *kvServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if r.Header.Get("X-Consul-Token") != "secret-token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	_, _ = w.Write([]byte(s.doc))
+}
+
+func TestRemote(t *testing.T) {
+	Convey("远程配置", t, func() {
+		dir := t.TempDir()
+		t.Setenv(config.EnvFile, filepath.Join(dir, "missing.env"))
+		unsetenv(t, config.EnvProfile, "GOSTAR_SERVER_PORT")
+		t.Setenv("CONSUL_TOKEN", "secret-token")
+
+		kv := &kvServer{}
+		kv.set("server:\n  port: 9100\nlog:\n  level: warn\n")
+		srv := httptest.NewServer(kv)
+		defer srv.Close()
+
+		cache := filepath.Join(dir, "cache", "remote.yaml")
+		writeFile(t, dir, "app.yaml", baseYAML+fmt.Sprintf(`remote:
+  enable: true
+  url: %q
+  headers:
+    X-Consul-Token: "env:CONSUL_TOKEN"
+  poll_interval: 50ms
+  cache_file: %q
+`, srv.URL, cache))
+
+		c, err := config.Load(dir)
+		So(err, ShouldBeNil)
+
+		Convey("远程值覆盖本地文件，并写入本地缓存", func() {
+			So(c.Server.Port, ShouldEqual, 9100)
+			So(c.Log.Level, ShouldEqual, "warn")
+			So(c.Server.Mode, ShouldEqual, "debug")
+			So(config.Sources()["server.port"], ShouldResemble, config.Source{Layer: config.LayerRemote, Origin: srv.URL})
+			cached, err := os.ReadFile(cache)
+			So(err, ShouldBeNil)
+			So(string(cached), ShouldContainSubstring, "port: 9100")
+		})
+
+		Convey("环境变量仍然优先于远程值", func() {
+			t.Setenv("GOSTAR_SERVER_PORT", "9200")
+			c, err := config.Load(dir)
+			So(err, ShouldBeNil)
+			So(c.Server.Port, ShouldEqual, 9200)
+		})
+
+		Convey("轮询发现新版本后热更新", func() {
+			stop := config.Watch()
+			defer stop()
+
+			kv.set("server:\n  port: 9101\nlog:\n  level: warn\n")
+			deadline := time.Now().Add(3 * time.Second)
+			for time.Now().Before(deadline) && config.Current().Server.Port != 9101 {
+				time.Sleep(20 * time.Millisecond)
+			}
+			So(config.Current().Server.Port, ShouldEqual, 9101)
+			So(config.C.Server.Port, ShouldEqual, 9100)
+		})
+
+		Convey("校验失败的新版本不生效，之后的热更新也不带上它", func() {
+			stop := config.Watch()
+			defer stop()
+
+			kv.set("server:\n  port: 70000\n")
+			kv.mu.Lock()
+			seen := kv.requests
+			kv.mu.Unlock()
+			for i := 0; i < 150; i++ {
+				kv.mu.Lock()
+				n := kv.requests
+				kv.mu.Unlock()
+				if n >= seen+3 {
+					break
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+			So(config.Current().Server.Port, ShouldEqual, 9100)
+			So(config.Reload(), ShouldBeNil)
+			So(config.Current().Server.Port, ShouldEqual, 9100)
+			cached, _ := os.ReadFile(cache)
+			So(string(cached), ShouldContainSubstring, "port: 9100")
+
+			kv.set("server:\n  port: 9102\n")
+			deadline := time.Now().Add(3 * time.Second)
+			for time.Now().Before(deadline) && config.Current().Server.Port != 9102 {
+				time.Sleep(20 * time.Millisecond)
+			}
+			So(config.Current().Server.Port, ShouldEqual, 9102)
+		})
+
+		Convey("远程不可达时使用本地缓存", func() {
+			srv.Close()
+			c, err := config.Load(dir)
+			So(err, ShouldBeNil)
+			So(c.Server.Port, ShouldEqual, 9100)
+			So(config.Sources()["server.port"].Origin, ShouldEqual, cache)
+		})
+
+		Convey("远程不可达且没有缓存时启动失败", func() {
+			srv.Close()
+			So(os.Remove(cache), ShouldBeNil)
+			_, err := config.Load(dir)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "remote config unavailable")
+		})
+	})
+}
+
+type demoConfig struct {
+	Name     string            `mapstructure:"name" validate:"required" comment:"插件名"`
+	Interval time.Duration     `mapstructure:"interval" validate:"min=0" comment:"刷新间隔"`
+	Tags     []string          `mapstructure:"tags"`
+	Labels   map[string]string `mapstructure:"labels"`
+	Retry    struct {
+		Max     int           `mapstructure:"max" comment:"最大重试次数"`
+		Backoff time.Duration `mapstructure:"backoff"`
+	} `mapstructure:"retry"`
+}
+
+var demoSection = config.NewSection("plugins.demo", "示例插件", func() demoConfig {
+	c := demoConfig{Name: "demo", Interval: 90 * time.Second, Tags: []string{"a", "b"}}
+	c.Retry.Max = 3
+	c.Retry.Backoff = 500 * time.Millisecond
+	return c
+}())
+
+func TestSection(t *testing.T) {
+	Convey("模块注册的配置节", t, func() {
+		dir := t.TempDir()
+		t.Setenv(config.EnvFile, filepath.Join(dir, "missing.env"))
+		unsetenv(t, config.EnvProfile, "GOSTAR_PLUGINS_DEMO_RETRY_MAX")
+
+		Convey("缺省值、文件和环境变量合并", func() {
+			writeFile(t, dir, "app.yaml", baseYAML+"  demo:\n    interval: 2m\n")
+			t.Setenv("GOSTAR_PLUGINS_DEMO_RETRY_MAX", "5")
+			_, err := config.Load(dir)
+			So(err, ShouldBeNil)
+
+			c := demoSection.Get()
+			So(c.Name, ShouldEqual, "demo")
+			So(c.Interval, ShouldEqual, 2*time.Minute)
+			So(c.Tags, ShouldResemble, []string{"a", "b"})
+			So(c.Retry.Max, ShouldEqual, 5)
+			So(c.Retry.Backoff, ShouldEqual, 500*time.Millisecond)
+			So(config.Sources()["plugins.demo.name"].Layer, ShouldEqual, config.LayerDefault)
+			// 文件中同级的其他插件不受影响
+			So(config.IsSet("plugins.metrics.enabled"), ShouldBeTrue)
+		})
+
+		Convey("随整个配置一起校验", func() {
+			writeFile(t, dir, "app.yaml", baseYAML+"  demo:\n    name: \"\"\n    interval: 5\n")
+			_, err := config.Load(dir)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "plugins.demo.name")
+			So(err.Error(), ShouldContainSubstring, "plugins.demo.interval")
+		})
+
+		Convey("热更新后 Get 返回新值", func() {
+			writeFile(t, dir, "app.yaml", baseYAML)
+			_, err := config.Load(dir)
+			So(err, ShouldBeNil)
+			writeFile(t, dir, "app.yaml", baseYAML+"  demo:\n    tags: [x]\n")
+			So(config.Reload(), ShouldBeNil)
+			So(demoSection.Get().Tags, ShouldResemble, []string{"x"})
+		})
+
+		Convey("生成带注释的样例配置，解析后与缺省值一致", func() {
+			sample := string(config.Sample())
+			So(sample, ShouldContainSubstring, "plugins:\n  # 示例插件\n  demo:\n    # 插件名\n    name: \"demo\"\n")
+			So(sample, ShouldContainSubstring, "interval: 90s")
+			So(sample, ShouldContainSubstring, "      # 最大重试次数\n      max: 3\n")
+
+			writeFile(t, dir, "app.yaml", sample)
+			t.Setenv("GOSTAR_MYSQL_DSN", "user:pass@tcp(127.0.0.1:3306)/sample")
+			t.Setenv("GOSTAR_REDIS_ADDR", "127.0.0.1:6379")
+			t.Setenv("GOSTAR_JWT_SECRET", "sample")
+			_, err := config.Load(dir)
+			So(err, ShouldBeNil)
+			So(demoSection.Get(), ShouldResemble, demoSection.Default())
+			for _, s := range config.Settings() {
+				if strings.HasPrefix(s.Key, "plugins.demo.") {
+					So(s.Source.Layer, ShouldEqual, config.LayerFile)
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// 环境变量约定
+const (
+	EnvPrefix  = "GOSTAR_"         // 配置覆盖变量前缀，GOSTAR_MYSQL_DSN -> mysql.dsn
+	EnvProfile = "GOSTAR_ENV"      // 环境名，选择 app.{env}.yaml
+	EnvFile    = "GOSTAR_ENV_FILE" // .env 文件路径，缺省为工作目录下的 .env
+)
+
+// keySep 显式的层级分隔符，用于配置文件和结构体中都没有的 key，
+// 如 GOSTAR_PLUGINS__METRICS__ENABLED -> plugins.metrics.enabled
+const keySep = "__"
+
+// mergeProfile 合并 app.{env}.yaml，与基础配置在同一目录；文件不存在时忽略。返回合并的文件路径。
+func mergeProfile(nv *viper.Viper, env string) (string, error) {
+	if env == "" {
+		return "", nil
+	}
+	dir := filepath.Dir(nv.ConfigFileUsed())
+	file := filepath.Join(dir, "app."+env+".yaml")
+	if _, err := os.Stat(file); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	nv.SetConfigFile(file)
+	if err := nv.MergeInConfig(); err != nil {
+		return "", fmt.Errorf("failed to merge %s: %w", file, err)
+	}
+	return file, nil
+}
+
+// envFile .env 文件路径
+func envFile() string {
+	if f := os.Getenv(EnvFile); f != "" {
+		return f
+	}
+	return ".env"
+}
+
+// readDotenv 解析 .env 文件，文件不存在时返回空
+func readDotenv(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: missing '='", file, n)
+		}
+		vars[strings.TrimSpace(key)] = unquote(strings.TrimSpace(val))
+	}
+	return vars, sc.Err()
+}
+
+// unquote 去掉引号；未加引号的值去掉行尾 " #" 注释
+func unquote(val string) string {
+	if len(val) >= 2 {
+		switch {
+		case val[0] == '"' && val[len(val)-1] == '"':
+			return strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(val[1 : len(val)-1])
+		case val[0] == '\'' && val[len(val)-1] == '\'':
+			return val[1 : len(val)-1]
+		}
+	}
+	if i := strings.Index(val, " #"); i >= 0 {
+		val = strings.TrimSpace(val[:i])
+	}
+	return val
+}
+
+// environ 合并 .env 和进程环境变量，进程环境变量优先
+func environ(dotenv map[string]string) map[string]string {
+	vars := make(map[string]string, len(dotenv))
+	for k, val := range dotenv {
+		vars[k] = val
+	}
+	for _, kv := range os.Environ() {
+		if k, val, ok := strings.Cut(kv, "="); ok {
+			vars[k] = val
+		}
+	}
+	return vars
+}
+
+// applyEnv 把 GOSTAR_* 变量映射到配置 key 并覆盖，返回 key -> 变量名。
+// 已知 key（配置文件、Config 结构体和已注册配置节中出现的）按 "." -> "_" 匹配，
+// 其余变量只有使用 "__" 分隔层级时才生效。按 key 排序依次设置，结果确定。
+func applyEnv(nv *viper.Viper, vars map[string]string) map[string]string {
+	known := make(map[string]string)
+	candidates := append(nv.AllKeys(), structKeys(reflect.TypeOf(Config{}), "")...)
+	for key, s := range sections {
+		if t := s.defaultValue().Type(); t.Kind() == reflect.Struct {
+			candidates = append(candidates, structKeys(t, key)...)
+		}
+	}
+	for _, key := range candidates {
+		known[envName(key)] = key
+	}
+
+	names := make(map[string]string)
+	for name := range vars {
+		if !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		if key, ok := known[name]; ok {
+			names[key] = name
+			continue
+		}
+		if rest := strings.TrimPrefix(name, EnvPrefix); strings.Contains(rest, keySep) {
+			names[strings.ToLower(strings.ReplaceAll(rest, keySep, "."))] = name
+		}
+	}
+
+	keys := make([]string, 0, len(names))
+	for key := range names {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		nv.Set(key, vars[names[key]])
+	}
+	return names
+}
+
+// envName 配置 key 对应的环境变量名：mysql.max_open_conns -> GOSTAR_MYSQL_MAX_OPEN_CONNS
+func envName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// structKeys 按 mapstructure tag 列出结构体的全部叶子 key
+func structKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if f.Type.Kind() == reflect.Struct {
+			keys = append(keys, structKeys(f.Type, name)...)
+			continue
+		}
+		keys = append(keys, name)
+	}
+	return keys
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+// 配置来源层，优先级从低到高
+const (
+	LayerDefault = "default" // 配置节注册的缺省值
+	LayerFile    = "file"    // app.yaml
+	LayerProfile = "profile" // app.{GOSTAR_ENV}.yaml
+	LayerRemote  = "remote"  // 远程配置或其本地缓存
+	LayerDotenv  = "dotenv"  // .env 文件
+	LayerEnv     = "env"     // 进程环境变量
+)
+
+// Source 配置 key 的来源
+type Source struct {
+	Layer  string `json:"layer"`
+	Origin string `json:"origin,omitempty"` // 文件路径或环境变量名
+	Ref    string `json:"ref,omitempty"`    // 值引用，如 file:/run/secrets/x、env:X；密文记为 enc
+}
+
+func (s Source) String() string {
+	out := s.Layer
+	if s.Origin != "" {
+		out += " " + s.Origin
+	}
+	if s.Ref != "" {
+		out += " via " + s.Ref
+	}
+	return out
+}
+
+// Setting 一个配置项的脱敏值及来源
+type Setting struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source Source      `json:"source"`
+}
+
+// Sources 返回每个 key 的来源
+func Sources() map[string]Source {
+	s := current.Load()
+	if s == nil {
+		return map[string]Source{}
+	}
+	out := make(map[string]Source, len(s.sources))
+	for key, src := range s.sources {
+		out[key] = src
+	}
+	return out
+}
+
+// Settings 返回全部配置项（按 key 排序）的脱敏值及来源
+func Settings() []Setting {
+	s := current.Load()
+	if s == nil {
+		return nil
+	}
+	return s.settings()
+}
+
+func (s *snapshot) settings() []Setting {
+	keys := s.v.AllKeys()
+	sort.Strings(keys)
+	out := make([]Setting, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, Setting{Key: key, Value: s.redact(key, s.v.Get(key)), Source: s.sources[key]})
+	}
+	return out
+}
+
+// markFile 记录配置文件中出现的 key
+func markFile(sources map[string]Source, file, layer string) error {
+	fv := viper.New()
+	fv.SetConfigFile(file)
+	if err := fv.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	for _, key := range fv.AllKeys() {
+		sources[key] = Source{Layer: layer, Origin: file}
+	}
+	return nil
+}
+
+// markDefaults 没有其他来源的 key 记为缺省值
+func markDefaults(nv *viper.Viper, sources map[string]Source) {
+	for _, key := range nv.AllKeys() {
+		if _, ok := sources[key]; !ok {
+			sources[key] = Source{Layer: LayerDefault}
+		}
+	}
+}
+
+// logChanges 记录热更新前后值或来源发生变化的 key，值已脱敏
+func logChanges(old, new *snapshot) {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, nv := range []*viper.Viper{old.v, new.v} {
+		for _, key := range nv.AllKeys() {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		ov, nv := old.v.Get(key), new.v.Get(key)
+		oldSrc, newSrc := old.sources[key], new.sources[key]
+		switch {
+		case !new.v.IsSet(key):
+			log.Printf("config: %s removed (was %v from %s)", key, old.redact(key, ov), oldSrc)
+		case !old.v.IsSet(key):
+			log.Printf("config: %s added: %v from %s", key, new.redact(key, nv), newSrc)
+		case !reflect.DeepEqual(ov, nv) || oldSrc != newSrc:
+			log.Printf("config: %s changed: %v -> %v from %s", key, old.redact(key, ov), new.redact(key, nv), newSrc)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 远程配置缺省值
+const (
+	defaultRemoteTimeout = 5 * time.Second
+	maxRemoteSize        = 10 << 20 // 远程文档最大 10MB
+)
+
+// RemoteConfig 远程配置源：GET url 返回整份 YAML/JSON 文档，合并在本地文件之上。
+// 兼容 Consul KV（/v1/kv/<key>?raw）和普通配置服务器，用 ETag / X-Consul-Index 判断版本。
+type RemoteConfig struct {
+	Enable       bool              `mapstructure:"enable"`
+	URL          string            `mapstructure:"url" validate:"required_if=Enable true" comment:"如 http://127.0.0.1:8500/v1/kv/go-star/config?raw"`
+	Format       string            `mapstructure:"format" validate:"omitempty,oneof=yaml json" comment:"yaml / json"`
+	Headers      map[string]string `mapstructure:"headers" comment:"请求头，如 X-Consul-Token: \"env:CONSUL_TOKEN\"，值支持 file:/env:/enc:"`
+	PollInterval time.Duration     `mapstructure:"poll_interval" validate:"min=0" comment:"轮询间隔，0 表示不轮询"`
+	Timeout      time.Duration     `mapstructure:"timeout" validate:"min=0"`
+	CacheFile    string            `mapstructure:"cache_file" comment:"最近一次成功加载的文档，远程不可达时使用"`
+}
+
+// remoteSource 远程文档及其版本
+type remoteSource struct {
+	cfg     RemoteConfig
+	headers map[string]string // 已解析引用的请求头
+	client  *http.Client
+
+	mu      sync.Mutex
+	etag    string
+	version string
+	raw     []byte
+	data    map[string]interface{}
+	keys    []string
+	origin  string // 当前数据来自 url 还是缓存文件
+	dirty   bool   // 有新数据尚未写入缓存
+
+	pending *remoteDoc // 轮询到的新版本，校验通过前只参与这一次构建
+}
+
+// remoteDoc 拉取到的一个版本的远程文档
+type remoteDoc struct {
+	etag    string
+	version string
+	raw     []byte
+	data    map[string]interface{}
+	keys    []string
+}
+
+// remote 当前的远程配置源，由 mu 保护；未启用时为 nil
+var remote *remoteSource
+
+// mergeRemote 按 remote 一节加载远程配置并合并，记录来源
+func mergeRemote(nv *viper.Viper, r *resolver, sources map[string]Source) error {
+	var rc RemoteConfig
+	if err := unmarshalSection(nv, "remote", &rc); err != nil {
+		return fmt.Errorf("failed to decode remote config settings: %w", err)
+	}
+	if !rc.Enable {
+		remote = nil
+		return nil
+	}
+	if remote == nil || !reflect.DeepEqual(remote.cfg, rc) {
+		rs, err := newRemoteSource(rc, r)
+		if err != nil {
+			return err
+		}
+		remote = rs
+	}
+
+	rs := remote
+	rs.mu.Lock()
+	data, keys, origin := rs.data, rs.keys, rs.origin
+	if p := rs.pending; p != nil {
+		data, keys, origin = p.data, p.keys, rs.cfg.URL
+	}
+	rs.mu.Unlock()
+	if err := nv.MergeConfigMap(data); err != nil {
+		return fmt.Errorf("failed to merge remote config: %w", err)
+	}
+	for _, key := range keys {
+		sources[key] = Source{Layer: LayerRemote, Origin: origin}
+	}
+	return nil
+}
+
+// newRemoteSource 首次加载远程文档，不可达时使用本地缓存，两者都不可用时报错
+func newRemoteSource(cfg RemoteConfig, r *resolver) (*remoteSource, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultRemoteTimeout
+	}
+	rs := &remoteSource{
+		cfg:     cfg,
+		headers: make(map[string]string, len(cfg.Headers)),
+		client:  &http.Client{Timeout: timeout},
+	}
+	for name, val := range cfg.Headers {
+		v, _, _, err := r.resolve(val)
+		if err != nil {
+			return nil, fmt.Errorf("remote.headers.%s: %w", name, err)
+		}
+		rs.headers[name] = v
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// 首次加载的文档随整个配置一起校验，失败时 Load 报错，不会留下
+	doc, err := rs.fetch(ctx)
+	if err == nil {
+		rs.commit(doc)
+		return rs, nil
+	}
+	if cerr := rs.readCache(); cerr != nil {
+		return nil, fmt.Errorf("remote config unavailable: %v; no usable cache: %v", err, cerr)
+	}
+	log.Printf("config: remote %s unreachable (%v), using cached copy %s", cfg.URL, err, cfg.CacheFile)
+	return rs, nil
+}
+
+// fetch 拉取远程文档，版本未变化时返回 nil；新文档只解析不生效，由调用方校验后 commit
+func (rs *remoteSource) fetch(ctx context.Context) (*remoteDoc, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rs.cfg.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, val := range rs.headers {
+		req.Header.Set(name, val)
+	}
+	rs.mu.Lock()
+	if rs.etag != "" {
+		req.Header.Set("If-None-Match", rs.etag)
+	}
+	rs.mu.Unlock()
+
+	resp, err := rs.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("GET %s: %s", rs.cfg.URL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSize))
+	if err != nil {
+		return nil, err
+	}
+
+	etag := resp.Header.Get("ETag")
+	version := etag
+	if version == "" {
+		version = resp.Header.Get("X-Consul-Index")
+	}
+
+	rs.mu.Lock()
+	same := (version != "" && version == rs.version) || (version == "" && bytes.Equal(body, rs.raw))
+	rs.mu.Unlock()
+	if same {
+		return nil, nil
+	}
+	data, keys, err := parseRemote(body, rs.cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteDoc{etag: etag, version: version, raw: body, data: data, keys: keys}, nil
+}
+
+// commit 新文档校验通过后生效，等待写入缓存
+func (rs *remoteSource) commit(doc *remoteDoc) {
+	if doc == nil {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.etag, rs.version = doc.etag, doc.version
+	rs.raw, rs.data, rs.keys = doc.raw, doc.data, doc.keys
+	rs.origin = rs.cfg.URL
+	rs.dirty = true
+}
+
+// reloadRemote 带上新文档重新构建配置，校验通过才替换远程数据和版本；
+// 失败时保留上一版本，下次轮询重新拉取
+func reloadRemote(rs *remoteSource, doc *remoteDoc) error {
+	mu.Lock()
+	defer mu.Unlock()
+	rs.mu.Lock()
+	rs.pending = doc
+	rs.mu.Unlock()
+	defer func() {
+		rs.mu.Lock()
+		rs.pending = nil
+		rs.mu.Unlock()
+	}()
+	return reload(func() { rs.commit(doc) })
+}
+
+// readCache 从本地缓存加载
+func (rs *remoteSource) readCache() error {
+	if rs.cfg.CacheFile == "" {
+		return fmt.Errorf("remote.cache_file is not set")
+	}
+	body, err := os.ReadFile(rs.cfg.CacheFile)
+	if err != nil {
+		return err
+	}
+	data, keys, err := parseRemote(body, rs.cfg.Format)
+	if err != nil {
+		return fmt.Errorf("%s: %w", rs.cfg.CacheFile, err)
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.raw, rs.data, rs.keys = body, data, keys
+	rs.origin = rs.cfg.CacheFile
+	return nil
+}
+
+// saveCache 把已生效的远程文档写入缓存文件（先写临时文件再改名）
+func (rs *remoteSource) saveCache() error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if !rs.dirty || rs.cfg.CacheFile == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(rs.cfg.CacheFile), 0o700); err != nil {
+		return err
+	}
+	tmp := rs.cfg.CacheFile + ".tmp"
+	if err := os.WriteFile(tmp, rs.raw, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, rs.cfg.CacheFile); err != nil {
+		return err
+	}
+	rs.dirty = false
+	return nil
+}
+
+// saveRemoteCache 新配置生效后更新远程缓存，调用方持有 mu
+func saveRemoteCache() {
+	if remote == nil {
+		return
+	}
+	if err := remote.saveCache(); err != nil {
+		log.Printf("config: write remote cache %s: %v", remote.cfg.CacheFile, err)
+	}
+}
+
+// parseRemote 解析远程文档；文档中的 remote 一节被忽略，远程配置不能修改自身
+func parseRemote(body []byte, format string) (map[string]interface{}, []string, error) {
+	if format == "" {
+		format = "yaml"
+	}
+	tv := viper.New()
+	tv.SetConfigType(format)
+	if err := tv.ReadConfig(bytes.NewReader(body)); err != nil {
+		return nil, nil, fmt.Errorf("parse remote config: %w", err)
+	}
+	data := tv.AllSettings()
+	delete(data, "remote")
+	var keys []string
+	for _, key := range tv.AllKeys() {
+		if !strings.HasPrefix(key, "remote.") {
+			keys = append(keys, key)
+		}
+	}
+	return data, keys, nil
+}
+
+// pollRemote 按 remote.poll_interval 轮询，版本变化后走热更新流程。返回停止函数。
+func pollRemote() (stop func()) {
+	mu.Lock()
+	rs := remote
+	mu.Unlock()
+	if rs == nil || rs.cfg.PollInterval <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		t := time.NewTicker(rs.cfg.PollInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			// 热更新可能替换了远程配置源
+			mu.Lock()
+			rs := remote
+			mu.Unlock()
+			if rs == nil {
+				continue
+			}
+			doc, err := rs.fetch(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("config: poll remote %s: %v", rs.cfg.URL, err)
+				}
+				continue
+			}
+			if doc == nil {
+				continue
+			}
+			log.Printf("config: remote %s changed (version %q)", rs.cfg.URL, doc.version)
+			if err := reloadRemote(rs, doc); err != nil {
+				log.Printf("config: remote %s version %q rejected, keeping version %q: %v",
+					rs.cfg.URL, doc.version, rs.currentVersion(), err)
+			}
+		}
+	}()
+	return cancel
+}
+
+func (rs *remoteSource) currentVersion() string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.version
+}
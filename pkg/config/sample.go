@@ -0,0 +1,134 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sample 按已注册的配置节生成带注释的样例 app.yaml，值为各节的缺省值。
+// 字段注释取自 comment tag，节注释取自 NewSection 的 doc。
+func Sample() []byte {
+	mu.Lock()
+	list := sortedSections()
+	mu.Unlock()
+
+	var b bytes.Buffer
+	b.WriteString("# 样例配置，由 `app config sample` 按各模块注册的配置节生成，值为缺省值。\n")
+	b.WriteString("# 字符串值支持 file:<路径>、env:<变量名>、enc:<密文> 引用。\n")
+
+	// open 当前已输出的父级路径，如 plugins.a 和 plugins.b 共用 plugins:
+	var open []string
+	for _, s := range list {
+		path := strings.Split(s.sectionKey(), ".")
+		n := 0
+		for n < len(open) && n < len(path)-1 && open[n] == path[n] {
+			n++
+		}
+		open = open[:n]
+		if n == 0 {
+			b.WriteString("\n")
+		}
+		for i := n; i < len(path)-1; i++ {
+			fmt.Fprintf(&b, "%s%s:\n", indent(i), path[i])
+			open = append(open, path[i])
+		}
+
+		depth := len(path) - 1
+		writeComment(&b, depth, s.sectionDoc())
+		name := path[depth]
+		v := s.defaultValue()
+		if v.Kind() != reflect.Struct {
+			fmt.Fprintf(&b, "%s%s: %s\n", indent(depth), name, formatValue(v))
+			continue
+		}
+		fmt.Fprintf(&b, "%s%s:\n", indent(depth), name)
+		writeFields(&b, v, depth+1)
+	}
+	return b.Bytes()
+}
+
+// writeFields 输出结构体字段，嵌套结构体展开为子节，结构体切片每项一行
+func writeFields(b *bytes.Buffer, v reflect.Value, depth int) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+		if name == "" || name == "-" || !f.IsExported() {
+			continue
+		}
+		writeComment(b, depth, f.Tag.Get("comment"))
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			fmt.Fprintf(b, "%s%s:\n", indent(depth), name)
+			writeFields(b, fv, depth+1)
+			continue
+		}
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct && fv.Len() > 0 {
+			fmt.Fprintf(b, "%s%s:\n", indent(depth), name)
+			for j := 0; j < fv.Len(); j++ {
+				fmt.Fprintf(b, "%s- %s\n", indent(depth+1), formatValue(fv.Index(j)))
+			}
+			continue
+		}
+		fmt.Fprintf(b, "%s%s: %s\n", indent(depth), name, formatValue(fv))
+	}
+}
+
+func writeComment(b *bytes.Buffer, depth int, text string) {
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(b, "%s# %s\n", indent(depth), line)
+	}
+}
+
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// formatValue 把缺省值写成 YAML 值，切片、map 和结构体使用流式写法，结构体省略零值字段
+func formatValue(v reflect.Value) string {
+	if v.Type() == durationType {
+		return formatDuration(time.Duration(v.Int()))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatValue(v.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Map:
+		items := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			items = append(items, formatValue(k)+": "+formatValue(v.MapIndex(k)))
+		}
+		sort.Strings(items)
+		return "{" + strings.Join(items, ", ") + "}"
+	case reflect.Struct:
+		t := v.Type()
+		items := make([]string, 0, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("mapstructure"), ",")[0]
+			if name == "" || name == "-" || !t.Field(i).IsExported() || v.Field(i).IsZero() {
+				continue
+			}
+			items = append(items, name+": "+formatValue(v.Field(i)))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "null"
+		}
+		return formatValue(v.Elem())
+	}
+	return fmt.Sprint(v.Interface())
+}
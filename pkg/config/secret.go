@@ -0,0 +1,171 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvMasterKey 解密 enc: 值的主密钥（base64 编码的 32 字节），只从环境变量或 .env 读取
+const EnvMasterKey = "GOSTAR_MASTER_KEY"
+
+// 值引用前缀
+const (
+	prefixFile = "file:" // file:/run/secrets/jwt_secret，读取文件内容（去掉末尾换行）
+	prefixEnv  = "env:"  // env:JWT_SECRET，读取环境变量
+	prefixEnc  = "enc:"  // enc:<base64>，AES-256-GCM 密文
+)
+
+// resolver 解析 file:/env:/enc: 引用，主密钥在第一次遇到 enc: 时读取
+type resolver struct {
+	vars      map[string]string
+	key       []byte
+	keyErr    error
+	keyLoaded bool
+}
+
+// resolve 解析单个值。不是引用时 ok 为 false；ref 为引用描述（密文只记为 "enc"）。
+func (r *resolver) resolve(s string) (val, ref string, ok bool, err error) {
+	switch {
+	case strings.HasPrefix(s, prefixFile):
+		val, err = readSecretFile(strings.TrimPrefix(s, prefixFile))
+		return val, s, true, err
+	case strings.HasPrefix(s, prefixEnv):
+		name := strings.TrimPrefix(s, prefixEnv)
+		val, set := r.vars[name]
+		if !set {
+			err = fmt.Errorf("environment variable %s is not set", name)
+		}
+		return val, s, true, err
+	case strings.HasPrefix(s, prefixEnc):
+		if !r.keyLoaded {
+			r.key, r.keyErr = parseMasterKey(r.vars[EnvMasterKey])
+			r.keyLoaded = true
+		}
+		if r.keyErr != nil {
+			return "", "enc", true, r.keyErr
+		}
+		val, err = Decrypt(s, r.key)
+		return val, "enc", true, err
+	}
+	return s, "", false, nil
+}
+
+// resolveSecrets 把 file:/env:/enc: 引用替换为实际值，返回被替换的 key -> 引用。
+// 全部引用都会尝试解析，错误汇总返回。
+func resolveSecrets(nv *viper.Viper, r *resolver) (map[string]string, error) {
+	resolved := make(map[string]string)
+	var problems []string
+
+	keys := nv.AllKeys()
+	sort.Strings(keys)
+	for _, k := range keys {
+		s, ok := nv.Get(k).(string)
+		if !ok {
+			continue
+		}
+		val, ref, ok, err := r.resolve(s)
+		if !ok {
+			continue
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", k, err))
+			continue
+		}
+		nv.Set(k, val)
+		resolved[k] = ref
+	}
+	if len(problems) > 0 {
+		return nil, &Error{Problems: problems}
+	}
+	return resolved, nil
+}
+
+func readSecretFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// parseMasterKey 解析 base64 编码的 32 字节主密钥
+func parseMasterKey(s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("%s is not set", EnvMasterKey)
+	}
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not valid base64: %w", EnvMasterKey, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%s must be 32 bytes, got %d", EnvMasterKey, len(key))
+	}
+	return key, nil
+}
+
+// MasterKey 从环境变量读取主密钥
+func MasterKey() ([]byte, error) {
+	return parseMasterKey(os.Getenv(EnvMasterKey))
+}
+
+// GenerateKey 生成新的主密钥，返回 base64 编码
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Encrypt 用 AES-256-GCM 加密，返回可直接写入配置的 "enc:..." 值
+func Encrypt(plaintext string, key []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefixEnc + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 生成的值，"enc:" 前缀可省略
+func Decrypt(value string, key []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, prefixEnc))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted value: too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("decrypt failed: wrong master key or corrupted value")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
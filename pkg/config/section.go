@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// Section 由各包自己声明的配置节，新增组件不需要修改 Config。
+// 注册后缺省值参与合并（来源记为 default），GOSTAR_<KEY>_<FIELD> 环境变量同样生效；
+// 加载和热更新时随整个配置一起解析、校验；Provide 把解析结果注入 fx，Get 读取最新值；
+// Sample 按字段的 comment tag 生成带注释的样例配置。
+//
+//	var Section = config.NewSection("mysql", "MySQL 连接与连接池", Config{MaxOpen: 50})
+//	var Module = fx.Options(Section.Provide(), fx.Provide(New))
+type Section[T any] struct {
+	key string
+	doc string
+	def T
+}
+
+// section 注册表中的配置节，屏蔽类型参数
+type section interface {
+	sectionKey() string
+	sectionDoc() string
+	defaultValue() reflect.Value
+	decode(input interface{}, secrets map[string]bool) (interface{}, []string, error)
+}
+
+// sections 已注册的配置节，由 mu 保护
+var sections = map[string]section{}
+
+// NewSection 注册配置节，通常在包级变量中调用；同一个 key 重复注册会 panic。
+// key 可以带层级，如 "plugins.metrics"。
+func NewSection[T any](key, doc string, def T) *Section[T] {
+	s := &Section[T]{key: strings.ToLower(key), doc: doc, def: def}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := sections[s.key]; ok {
+		panic(fmt.Sprintf("config: section %q registered twice", s.key))
+	}
+	sections[s.key] = s
+
+	kv := make(map[string]interface{})
+	flatten(reflect.ValueOf(def), s.key, kv)
+	if err := setDefaults(kv); err != nil {
+		log.Printf("config: section %s: %v", s.key, err)
+	}
+	return s
+}
+
+// Key 配置节路径
+func (s *Section[T]) Key() string { return s.key }
+
+// Default 注册时的缺省值
+func (s *Section[T]) Default() T { return s.def }
+
+// Get 返回最新配置快照中的该节，未加载时返回缺省值
+func (s *Section[T]) Get() T {
+	if snap := current.Load(); snap != nil {
+		if v, ok := snap.sections[s.key].(T); ok {
+			return v
+		}
+	}
+	return s.def
+}
+
+// Subscribe 订阅该节的变化，见 Subscribe
+func (s *Section[T]) Subscribe(fn func(old, new T)) (unsubscribe func()) {
+	return Subscribe(s.key, fn)
+}
+
+// Provide 把该节注入 fx。注入的是启动时的值，需要热更新的字段请用 Get 或 Subscribe。
+func (s *Section[T]) Provide() fx.Option {
+	return fx.Provide(func(*Config) T { return s.Get() })
+}
+
+func (s *Section[T]) sectionKey() string          { return s.key }
+func (s *Section[T]) sectionDoc() string          { return s.doc }
+func (s *Section[T]) defaultValue() reflect.Value { return reflect.ValueOf(s.def) }
+
+func (s *Section[T]) decode(input interface{}, secrets map[string]bool) (interface{}, []string, error) {
+	var v T
+	problems, err := decodeValue(input, s.key, &v, secrets)
+	return v, problems, err
+}
+
+// sortedSections 按 key 排序的配置节，调用方持有 mu
+func sortedSections() []section {
+	list := make([]section, 0, len(sections))
+	for _, s := range sections {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].sectionKey() < list[j].sectionKey() })
+	return list
+}
+
+// flatten 把结构体按 mapstructure tag 展开为 key -> 缺省值；时长写成 "500ms" 形式，空切片和 map 跳过
+func flatten(v reflect.Value, prefix string, out map[string]interface{}) {
+	if v.Kind() != reflect.Struct {
+		if !isEmpty(v) {
+			out[prefix] = v.Interface()
+		}
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("mapstructure"), ",")[0]
+		if name == "" || name == "-" || !f.IsExported() {
+			continue
+		}
+		key := prefix + "." + name
+		fv := v.Field(i)
+		switch {
+		case fv.Kind() == reflect.Struct:
+			flatten(fv, key, out)
+		case fv.Type() == durationType:
+			out[key] = formatDuration(time.Duration(fv.Int()))
+		case !isEmpty(fv):
+			out[key] = fv.Interface()
+		}
+	}
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Invalid:
+		return true
+	}
+	return false
+}
+
+// formatDuration 按最大的整数单位输出，如 1h、90s、500ms
+func formatDuration(d time.Duration) string {
+	for _, u := range []struct {
+		d    time.Duration
+		unit string
+	}{{time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"}, {time.Millisecond, "ms"}} {
+		if d != 0 && d%u.d == 0 {
+			return fmt.Sprintf("%d%s", d/u.d, u.unit)
+		}
+	}
+	return d.String()
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+
+	"github.com/jiujuan/go-star/pkg/validator"
+)
+
+// Error 配置错误汇总，一次列出全部问题，启动时直接退出
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// decodeHook 字符串转 time.Duration（拒绝没有单位的数字），逗号分隔的字符串转切片
+var decodeHook = viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+	durationHook,
+	mapstructure.StringToSliceHookFunc(","),
+))
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// durationHook 把 "500ms"、"1h" 解析为 time.Duration。
+// 没有单位的数字（如 3600）会被当作纳秒，容易写错，因此除 0 外一律报错。
+func durationHook(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if to != durationType {
+		return data, nil
+	}
+	switch val := data.(type) {
+	case string:
+		if val == "" {
+			return time.Duration(0), nil
+		}
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q, use a value with a unit like \"500ms\" or \"1h\"", val)
+		}
+		return d, nil
+	case time.Duration:
+		return val, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		if reflect.ValueOf(val).IsZero() {
+			return time.Duration(0), nil
+		}
+		return nil, fmt.Errorf("duration %v has no unit, write it like \"%vs\"", val, val)
+	}
+	return data, nil
+}
+
+// decodeKey 从 mapstructure 错误中取出 key，如 "error decoding 'mysql.max_lifetime': ..."
+var decodeKey = regexp.MustCompile(`'([^']+)'`)
+
+// decode 解析并校验整个配置及全部已注册的配置节，全部问题汇总到 *Error；
+// secrets 中的 key 在错误信息里不显示值。调用方持有 mu。
+func decode(nv *viper.Viper, secrets map[string]bool) (*Config, map[string]interface{}, error) {
+	all := nv.AllSettings()
+	c := &Config{}
+	problems, err := decodeValue(all, "", c, secrets)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make(map[string]interface{}, len(sections))
+	for key, s := range sections {
+		v, p, err := s.decode(lookup(all, key), secrets)
+		if err != nil {
+			return nil, nil, err
+		}
+		problems = append(problems, p...)
+		values[key] = v
+	}
+
+	if len(problems) > 0 {
+		// server、log 等节同时属于 Config 和已注册的配置节，去掉重复的问题
+		sort.Strings(problems)
+		uniq := problems[:1]
+		for _, p := range problems[1:] {
+			if p != uniq[len(uniq)-1] {
+				uniq = append(uniq, p)
+			}
+		}
+		return nil, nil, &Error{Problems: uniq}
+	}
+	return c, values, nil
+}
+
+// decodeValue 把 input 解析到 out 并校验，prefix 为 input 所在的配置节，问题中的 key 均为完整路径
+func decodeValue(input interface{}, prefix string, out interface{}, secrets map[string]bool) ([]string, error) {
+	var problems []string
+	failed := make(map[string]bool)
+
+	if err := decodeMap(input, out); err != nil {
+		var me *mapstructure.Error
+		if !errors.As(err, &me) {
+			return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+		for _, msg := range me.Errors {
+			if m := decodeKey.FindStringSubmatchIndex(msg); m != nil {
+				key := msg[m[2]:m[3]]
+				if prefix != "" {
+					key = prefix + "." + key
+					msg = msg[:m[2]] + key + msg[m[3]:]
+				}
+				failed[key] = true
+			}
+			problems = append(problems, msg)
+		}
+	}
+
+	// 解析失败的 key 不再重复报告校验错误
+	for _, p := range validate(out, prefix, secrets) {
+		if !failed[p.key] {
+			problems = append(problems, p.msg)
+		}
+	}
+	return problems, nil
+}
+
+// decodeMap 与 viper.Unmarshal 使用相同的解析规则
+func decodeMap(input, out interface{}) error {
+	dc := &mapstructure.DecoderConfig{Result: out, WeaklyTypedInput: true}
+	decodeHook(dc)
+	d, err := mapstructure.NewDecoder(dc)
+	if err != nil {
+		return err
+	}
+	return d.Decode(input)
+}
+
+// lookup 按 "a.b" 路径从 AllSettings 的结果中取值，不存在时返回 nil。
+// 与 viper.Get 不同，取到的子树已合并缺省值、文件和环境变量各层。
+func lookup(m map[string]interface{}, key string) interface{} {
+	var cur interface{} = m
+	for _, part := range strings.Split(key, ".") {
+		mm, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = mm[part]
+	}
+	return cur
+}
+
+type problem struct {
+	key string
+	msg string
+}
+
+// configValidator 字段名取 mapstructure tag；min/max 用于数值和时长，改写默认的“字符长度”提示
+var configValidator = func() validator.Validator {
+	v := validator.NewWithTagName("mapstructure")
+	v.RegisterTranslation("min", "{field} must be at least {param}")
+	v.RegisterTranslation("max", "{field} must be at most {param}")
+	v.RegisterTranslation("required_if", "{field} is required when {param}")
+	return v
+}()
+
+// validate 按 validate tag 校验结构体，字段以完整配置 key 表示
+func validate(v interface{}, prefix string, secrets map[string]bool) []problem {
+	if reflect.Indirect(reflect.ValueOf(v)).Kind() != reflect.Struct {
+		return nil
+	}
+	err := configValidator.Validate(v)
+	if err == nil {
+		return nil
+	}
+	var ves validator.ValidationErrors
+	if !errors.As(err, &ves) {
+		return []problem{{msg: err.Error()}}
+	}
+	problems := make([]problem, 0, len(ves))
+	for _, ve := range ves {
+		// Namespace 形如 Config.mysql.dsn，去掉根结构体名
+		key := ve.Namespace[strings.Index(ve.Namespace, ".")+1:]
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		msg := strings.Replace(ve.Message, ve.Field, key, 1)
+		if ve.Kind == "string" && ve.Value != "" && !IsSensitive(key) && !secrets[key] {
+			msg += fmt.Sprintf(" (got %q)", ve.Value)
+		}
+		problems = append(problems, problem{key: key, msg: msg})
+	}
+	return problems
+}
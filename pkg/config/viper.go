@@ -1,65 +1,418 @@
 package config
 
 import (
+	"context"
+	"fmt"
 	"log"
-	"path/filepath"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/spf13/viper"
+	"go.uber.org/fx"
 )
 
+// Config 框架自身的配置：HTTP 服务、日志和远程配置源。
+// 各组件（mysql、redis、jwt、cache、插件）的配置由所在包通过 NewSection 注册，不在这里。
 type Config struct {
 	Server ServerConfig `mapstructure:"server"`
-	MySQL  MySQLConfig  `mapstructure:"mysql"`
-	Redis  RedisConfig  `mapstructure:"redis"`
-	JWT    JWTConfig    `mapstructure:"jwt"`
 	Log    LogConfig    `mapstructure:"log"`
+	Remote RemoteConfig `mapstructure:"remote"`
 }
 
 type ServerConfig struct {
-	Port int    `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
+	Port int    `mapstructure:"port" validate:"min=0,max=65535"`
+	Mode string `mapstructure:"mode" validate:"omitempty,oneof=debug release test" comment:"debug / release / test"`
+
+	ReadTimeout       time.Duration `mapstructure:"read_timeout" validate:"min=0" comment:"读取整个请求的超时"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout" validate:"min=0" comment:"读取请求头的超时"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout" validate:"min=0" comment:"写响应的超时"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout" validate:"min=0" comment:"keep-alive 空闲超时"`
+	MaxHeaderBytes    int           `mapstructure:"max_header_bytes" validate:"min=0" comment:"请求头最大字节数"`
+	ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout" validate:"min=0" comment:"优雅关闭时等待连接排空的最长时间"`
+
+	Listen ListenConfig `mapstructure:"listen"`
+
+	TLS TLSConfig `mapstructure:"tls"`
+	H2C bool      `mapstructure:"h2c" comment:"未启用 TLS 时允许明文 HTTP/2，仅用于内网流量"`
+
+	Admin AdminConfig `mapstructure:"admin" comment:"运维端口，health/metrics/pprof 等只在这里暴露"`
+
+	CORS CORSConfig `mapstructure:"cors" comment:"跨域配置，修改后无需重启"`
 }
 
-type MySQLConfig struct {
-	DSN         string `mapstructure:"dsn"`
-	MaxOpen     int    `mapstructure:"max_open_conns"`
-	MaxIdle     int    `mapstructure:"max_idle_conns"`
-	MaxLifetime int    `mapstructure:"max_lifetime"`
+// ListenConfig 监听方式：unix socket、SO_REUSEPORT、systemd socket activation、SIGHUP 热升级
+type ListenConfig struct {
+	Address        string        `mapstructure:"address" comment:"覆盖 port，如 \"127.0.0.1:8080\" 或 \"unix:/run/go-star.sock\""`
+	ReusePort      bool          `mapstructure:"reuse_port" comment:"开启 SO_REUSEPORT（仅 Linux）"`
+	Upgrade        bool          `mapstructure:"upgrade" comment:"收到 SIGHUP 时启动新进程并交接监听 socket"`
+	UpgradeTimeout time.Duration `mapstructure:"upgrade_timeout" validate:"min=0" comment:"等待新进程就绪的最长时间"`
 }
 
-type RedisConfig struct {
-	Addr     string `mapstructure:"addr"`
-	Password string `mapstructure:"password"`
-	DB       int    `mapstructure:"db"`
-	PoolSize int    `mapstructure:"pool_size"`
+// TLSConfig 主服务的 TLS / 双向 TLS 配置，证书文件变化后自动重新加载
+type TLSConfig struct {
+	Enable         bool          `mapstructure:"enable"`
+	CertFile       string        `mapstructure:"cert_file" validate:"required_if=Enable true"`
+	KeyFile        string        `mapstructure:"key_file" validate:"required_if=Enable true"`
+	ClientCAFile   string        `mapstructure:"client_ca_file" validate:"required_if=ClientAuth verify_if_given,required_if=ClientAuth require_and_verify" comment:"配置后校验客户端证书（双向 TLS），verify_if_given / require_and_verify 时必填"`
+	ClientAuth     string        `mapstructure:"client_auth" validate:"omitempty,oneof=none request require verify_if_given require_and_verify" comment:"none / request / require / verify_if_given / require_and_verify，配置 CA 时默认 require_and_verify"`
+	MinVersion     string        `mapstructure:"min_version" validate:"omitempty,oneof=1.2 1.3" comment:"1.2 / 1.3"`
+	ReloadInterval time.Duration `mapstructure:"reload_interval" validate:"min=0" comment:"证书文件检查间隔"`
 }
 
-type JWTConfig struct {
-	Secret string `mapstructure:"secret"`
-	Expire string `mapstructure:"expire"`
+// AdminConfig 运维端口：健康检查、指标、pprof、日志级别、配置查看，只在内网暴露
+type AdminConfig struct {
+	Enable  bool   `mapstructure:"enable"`
+	Address string `mapstructure:"address" comment:"监听地址，运维端口没有认证，缺省只监听本机；\"0.0.0.0\" 监听全部网卡"`
+	Port    int    `mapstructure:"port" validate:"required_if=Enable true,max=65535"`
+	Pprof   bool   `mapstructure:"pprof" comment:"是否挂载 /debug/pprof"`
+}
+
+// CORSConfig 跨域配置，修改后热更新生效
+type CORSConfig struct {
+	AllowOrigins []string      `mapstructure:"allow_origins" comment:"为空或包含 \"*\" 时允许任意来源"`
+	AllowMethods []string      `mapstructure:"allow_methods"`
+	AllowHeaders []string      `mapstructure:"allow_headers"`
+	MaxAge       time.Duration `mapstructure:"max_age" validate:"min=0" comment:"预检结果缓存时间"`
 }
 
 type LogConfig struct {
-	Level  string `mapstructure:"level"`
-	Format string `mapstructure:"format"`
+	Level    string            `mapstructure:"level" validate:"omitempty,oneof=trace debug info warn warning error fatal panic" comment:"trace / debug / info / warn / error，修改后无需重启"`
+	Format   string            `mapstructure:"format" validate:"omitempty,oneof=json text" comment:"json / text，console 和 file 未单独设置格式时使用，修改后无需重启"`
+	Modules  map[string]string `mapstructure:"modules" validate:"dive,oneof=trace debug info warn warning error fatal panic inherit" comment:"按模块（db / redis / http / service）单独设置级别，未列出的跟随 level"`
+	LevelTTL time.Duration     `mapstructure:"level_ttl" validate:"min=0" comment:"通过运维接口或 SIGUSR1/SIGUSR2 临时调整的级别在该时长后自动恢复，0 表示不恢复"`
+	Redact   RedactConfig      `mapstructure:"redact" comment:"日志脱敏，修改后无需重启"`
+	Sampling SamplingConfig    `mapstructure:"sampling" comment:"相同日志的采样限流，修改后无需重启"`
+	Console  ConsoleConfig     `mapstructure:"console" comment:"标准输出"`
+	File     FileConfig        `mapstructure:"file" comment:"文件输出，路径和切割规则修改后需重启"`
+}
+
+type ConsoleConfig struct {
+	Enable bool   `mapstructure:"enable"`
+	Format string `mapstructure:"format" validate:"omitempty,oneof=json text" comment:"为空时使用 log.format，修改后无需重启"`
+}
+
+// FileConfig 文件输出：path 记录所有级别，level_files 按级别另写一份，切割和保留规则相同。
+// rotate 为 daily / hourly 时在周期结束后把当前文件改名为 app-2006-01-02.log / app-2006-01-02T15.log；
+// 为 size 时按 max_size 切割（lumberjack）。
+type FileConfig struct {
+	Enable     bool          `mapstructure:"enable"`
+	Path       string        `mapstructure:"path" validate:"required_if=Enable true"`
+	Format     string        `mapstructure:"format" validate:"omitempty,oneof=json text" comment:"为空时使用 log.format，修改后无需重启"`
+	Rotate     string        `mapstructure:"rotate" validate:"oneof=daily hourly size" comment:"daily / hourly 按时间切割，size 按大小切割"`
+	MaxSize    int           `mapstructure:"max_size" validate:"min=0" comment:"rotate 为 size 时单个文件的上限（MB）"`
+	MaxAge     time.Duration `mapstructure:"max_age" validate:"min=0" comment:"旧文件保留时长，0 表示不按时间清理"`
+	MaxBackups int           `mapstructure:"max_backups" validate:"min=0" comment:"旧文件最多保留个数，0 表示不限"`
+	Compress   bool          `mapstructure:"compress" comment:"旧文件 gzip 压缩"`
+	LevelFiles []LevelFile   `mapstructure:"level_files" validate:"dive" comment:"按级别另写的文件，level 及更严重的级别写入 path"`
+}
+
+type LevelFile struct {
+	Level string `mapstructure:"level" validate:"oneof=trace debug info warn warning error fatal panic"`
+	Path  string `mapstructure:"path" validate:"required"`
+}
+
+// RedactConfig 日志脱敏：按字段名整体处理字段值，按正则处理消息和字符串字段中匹配的片段。
+// keep_prefix / keep_suffix 为保留的首尾字符数，如 3 和 4 把 13812345678 写成 138****5678；都为 0 时整体替换为 Mask。
+type RedactConfig struct {
+	Enable   bool            `mapstructure:"enable"`
+	Fields   []RedactField   `mapstructure:"fields" validate:"dive" comment:"按字段名脱敏，不区分大小写，嵌套 map 中的字段同样生效"`
+	Patterns []RedactPattern `mapstructure:"patterns" validate:"dive" comment:"按正则脱敏消息和字符串字段中匹配的片段"`
+}
+
+type RedactField struct {
+	Name       string `mapstructure:"name" validate:"required"`
+	KeepPrefix int    `mapstructure:"keep_prefix" validate:"min=0"`
+	KeepSuffix int    `mapstructure:"keep_suffix" validate:"min=0"`
+}
+
+type RedactPattern struct {
+	Name       string `mapstructure:"name"`
+	Regex      string `mapstructure:"regex" validate:"required"`
+	KeepPrefix int    `mapstructure:"keep_prefix" validate:"min=0"`
+	KeepSuffix int    `mapstructure:"keep_suffix" validate:"min=0"`
+}
+
+// SamplingConfig 日志采样：每个统计窗口内同一级别、同一消息的前 first 条照常输出，
+// 之后每 thereafter 条输出 1 条（0 表示全部丢弃）；窗口结束时输出被丢弃条数的汇总
+type SamplingConfig struct {
+	Enable   bool                    `mapstructure:"enable"`
+	Interval time.Duration           `mapstructure:"interval" validate:"required_if=Enable true,min=0" comment:"统计窗口"`
+	Levels   map[string]SamplingRule `mapstructure:"levels" validate:"dive,keys,oneof=trace debug info warn warning error,endkeys" comment:"按级别设置，未列出的级别不采样"`
+}
+
+type SamplingRule struct {
+	First      int `mapstructure:"first" validate:"min=0"`
+	Thereafter int `mapstructure:"thereafter" validate:"min=0"`
 }
 
+// 框架自身的配置节，缺省值与 config/app.yaml 一致
+var (
+	ServerSection = NewSection("server", "HTTP 服务", ServerConfig{
+		Port:              8080,
+		Mode:              "debug",
+		ReadTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+		ShutdownTimeout:   30 * time.Second,
+		Listen:            ListenConfig{UpgradeTimeout: 30 * time.Second},
+		TLS:               TLSConfig{MinVersion: "1.2", ReloadInterval: 10 * time.Second},
+		Admin:             AdminConfig{Address: "127.0.0.1", Port: 9090},
+		CORS: CORSConfig{
+			AllowOrigins: []string{"*"},
+			AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
+			AllowHeaders: []string{"Origin", "Content-Type", "Authorization", "X-Request-ID"},
+			MaxAge:       12 * time.Hour,
+		},
+	})
+	LogSection = NewSection("log", "日志", LogConfig{
+		Level:    "info",
+		Format:   "json",
+		LevelTTL: 30 * time.Minute,
+		Redact: RedactConfig{
+			Enable: true,
+			Fields: []RedactField{
+				{Name: "authorization"}, {Name: "password"}, {Name: "token"},
+				{Name: "access_token"}, {Name: "refresh_token"},
+				{Name: "mobile", KeepPrefix: 3, KeepSuffix: 4},
+				{Name: "idcard", KeepPrefix: 6, KeepSuffix: 4},
+			},
+			Patterns: []RedactPattern{
+				{Name: "bearer", Regex: `(?i)\bbearer\s+[\w.~+/-]+=*`, KeepPrefix: 7},
+				{Name: "jwt", Regex: `\beyJ[\w-]+\.[\w-]+\.[\w-]+`},
+				{Name: "idcard", Regex: `\b\d{17}[\dXx]\b`, KeepPrefix: 6, KeepSuffix: 4},
+				{Name: "mobile", Regex: `\b1[3-9]\d{9}\b`, KeepPrefix: 3, KeepSuffix: 4},
+			},
+		},
+		Sampling: SamplingConfig{
+			Enable:   true,
+			Interval: time.Second,
+			Levels: map[string]SamplingRule{
+				"debug": {First: 100, Thereafter: 100},
+				"info":  {First: 100, Thereafter: 100},
+				"warn":  {First: 100, Thereafter: 100},
+				"error": {First: 100, Thereafter: 100},
+			},
+		},
+		Console: ConsoleConfig{Enable: true},
+		File: FileConfig{
+			Path:       "logs/app.log",
+			Rotate:     "daily",
+			MaxSize:    100,
+			MaxAge:     7 * 24 * time.Hour,
+			MaxBackups: 30,
+			Compress:   true,
+			LevelFiles: []LevelFile{{Level: "error", Path: "logs/error.log"}},
+		},
+	})
+	RemoteSection = NewSection("remote", "远程配置：GET url 返回整份 YAML/JSON，合并在本地文件之上、环境变量之下；\n按 poll_interval 轮询（ETag / X-Consul-Index），远程不可达时使用 cache_file", RemoteConfig{
+		Format:       "yaml",
+		PollInterval: 30 * time.Second,
+		Timeout:      defaultRemoteTimeout,
+	})
+)
+
+// C 启动时加载的配置，供 fx 注入。热更新不会修改它，需要感知变化的值请用 Current() 或 Subscribe。
 var C *Config
 
+// snapshot 一次完整加载的结果，热更新时整体原子替换
+type snapshot struct {
+	v       *viper.Viper // 供按节读取（插件等）使用
+	c       *Config
+	files   []string          // 参与合并的配置文件，热更新时监听
+	secrets map[string]bool   // 由 file:/env:/enc: 引用解析出的 key，输出时一律脱敏
+	sources map[string]Source // 每个 key 的来源
+
+	sections map[string]interface{} // 已注册配置节的解析结果
+}
+
+var (
+	current atomic.Pointer[snapshot]
+
+	// mu 串行化 Load、配置节注册和热更新
+	mu       sync.Mutex
+	loadPath string
+	defaults = map[string]interface{}{}
+)
+
+// Current 返回最新的配置快照，热更新后立即可见；返回值只读
+func Current() *Config {
+	if s := current.Load(); s != nil {
+		return s.c
+	}
+	return nil
+}
+
+// instance 当前快照的 viper 实例，未加载时为 nil
+func instance() *viper.Viper {
+	if s := current.Load(); s != nil {
+		return s.v
+	}
+	return nil
+}
+
+// Init 加载配置，失败直接退出进程
 func Init(path string) {
-	v := viper.New()
-	v.SetConfigName("config")
-	v.SetConfigType("yaml")
-	v.AddConfigPath(path)
-	v.AddConfigPath(".")
-	v.AddConfigPath("./config")
-	if err := v.ReadInConfig(); err != nil {
-		log.Fatalf("failed to read config: %v", err)
+	if _, err := Load(path); err != nil {
+		log.Fatal(err)
 	}
-	C = &Config{}
-	if err := v.Unmarshal(C); err != nil {
-		log.Fatalf("failed to unmarshal config: %v", err)
+}
+
+// Load 加载配置并设置全局 C。优先级从低到高：
+//
+//  1. app.yaml 基础配置
+//  2. app.{GOSTAR_ENV}.yaml 环境配置（可选）
+//  3. 远程配置（可选，remote 一节，不可达时使用本地缓存）
+//  4. .env 文件中的 GOSTAR_* 变量（可选，路径由 GOSTAR_ENV_FILE 指定）
+//  5. 进程环境变量 GOSTAR_*
+//
+// 之后解析值引用：file:<路径>、env:<变量名>、enc:<密文>（主密钥见 GOSTAR_MASTER_KEY）。
+func Load(path string) (*Config, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	// 重新加载时重新拉取远程配置
+	remote = nil
+	s, err := build(path)
+	if err != nil {
+		return nil, err
 	}
+	loadPath = path
+	current.Store(s)
+	C = s.c
+	saveRemoteCache()
+	return s.c, nil
 }
 
-var Module = fx.Provide(func() *Config { return C })
\ No newline at end of file
+// build 按优先级合并各层配置，解析并校验
+func build(path string) (*snapshot, error) {
+	nv := viper.New()
+	nv.SetConfigName("app")
+	nv.SetConfigType("yaml")
+	nv.AddConfigPath(path)
+	nv.AddConfigPath(".")
+	nv.AddConfigPath("./config")
+	if err := nv.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	files := []string{nv.ConfigFileUsed()}
+	sources := make(map[string]Source)
+	for _, key := range nv.AllKeys() {
+		sources[key] = Source{Layer: LayerFile, Origin: files[0]}
+	}
+
+	dotenv, err := readDotenv(envFile())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	vars := environ(dotenv)
+	profile, err := mergeProfile(nv, vars[EnvProfile])
+	if err != nil {
+		return nil, err
+	}
+	if profile != "" {
+		files = append(files, profile)
+		if err := markFile(sources, profile, LayerProfile); err != nil {
+			return nil, err
+		}
+	}
+	// 环境变量通过 Set 覆盖，优先级高于之后合并的远程配置
+	envNames := applyEnv(nv, vars)
+	r := &resolver{vars: vars}
+	if err := mergeRemote(nv, r, sources); err != nil {
+		return nil, err
+	}
+	for key, name := range envNames {
+		if _, ok := os.LookupEnv(name); ok {
+			sources[key] = Source{Layer: LayerEnv, Origin: name}
+		} else {
+			sources[key] = Source{Layer: LayerDotenv, Origin: envFile() + ":" + name}
+		}
+	}
+
+	resolved, err := resolveSecrets(nv, r)
+	if err != nil {
+		return nil, err
+	}
+	secrets := make(map[string]bool, len(resolved))
+	for key, ref := range resolved {
+		secrets[key] = true
+		src := sources[key]
+		src.Ref = ref
+		sources[key] = src
+	}
+
+	for key, val := range defaults {
+		nv.SetDefault(key, val)
+	}
+	markDefaults(nv, sources)
+	c, values, err := decode(nv, secrets)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot{v: nv, c: c, files: files, secrets: secrets, sources: sources, sections: values}, nil
+}
+
+// setDefaults 记录缺省值，已加载时按原路径重新构建快照并整体替换，不修改正在使用的快照；调用方持有 mu
+func setDefaults(kv map[string]interface{}) error {
+	for key, val := range kv {
+		defaults[key] = val
+	}
+	old := current.Load()
+	if old == nil || len(kv) == 0 {
+		return nil
+	}
+	s, err := build(loadPath)
+	if err != nil {
+		return err
+	}
+	current.Store(s)
+	notify(old.v, s.v)
+	return nil
+}
+
+// UnmarshalKey 把某一节配置解析到 out，节不存在时 out 保持不变
+func UnmarshalKey(key string, out interface{}) error {
+	return unmarshalSection(instance(), key, out)
+}
+
+func unmarshalSection(nv *viper.Viper, key string, out interface{}) error {
+	if nv == nil {
+		return nil
+	}
+	if key == "" {
+		return nv.Unmarshal(out, decodeHook)
+	}
+	if !nv.IsSet(key) {
+		return nil
+	}
+	// viper.UnmarshalKey 只取最高优先级层中的子树，会漏掉其他层的字段
+	return decodeMap(lookup(nv.AllSettings(), key), out)
+}
+
+// IsSet 配置中是否存在 key
+func IsSet(key string) bool {
+	nv := instance()
+	return nv != nil && nv.IsSet(key)
+}
+
+// Module 提供 *Config，并在应用运行期间监听配置文件变化
+var Module = fx.Options(
+	fx.Provide(func() *Config { return C }),
+	fx.Invoke(func(lc fx.Lifecycle) {
+		var stop func()
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				stop = Watch()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				stop()
+				return nil
+			},
+		})
+	}),
+)
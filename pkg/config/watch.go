@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// reloadDelay 合并编辑器保存时触发的多次文件事件
+const reloadDelay = 200 * time.Millisecond
+
+type subscriber struct {
+	id     uint64
+	notify func(old, new *viper.Viper)
+}
+
+var (
+	subsMu  sync.Mutex
+	subs    []subscriber
+	nextSub uint64
+
+	timerMu     sync.Mutex
+	reloadTimer *time.Timer
+)
+
+// Subscribe 订阅某一节配置（如 "log"、"server.cors"，"" 表示整个配置）的变化。
+// 热更新后该节解析结果与之前不同时回调 fn，回调在热更新的 goroutine 中串行执行。
+// 返回取消订阅的函数。
+//
+//	config.Subscribe("log", func(old, new config.LogConfig) { ... })
+func Subscribe[T any](section string, fn func(old, new T)) (unsubscribe func()) {
+	return subscribe(func(oldV, newV *viper.Viper) {
+		var o, n T
+		if err := unmarshalSection(oldV, section, &o); err != nil {
+			log.Printf("config: decode previous %q: %v", section, err)
+			return
+		}
+		if err := unmarshalSection(newV, section, &n); err != nil {
+			log.Printf("config: decode %q: %v", section, err)
+			return
+		}
+		if !reflect.DeepEqual(o, n) {
+			fn(o, n)
+		}
+	})
+}
+
+func subscribe(notify func(old, new *viper.Viper)) func() {
+	subsMu.Lock()
+	defer subsMu.Unlock()
+	nextSub++
+	id := nextSub
+	subs = append(subs, subscriber{id: id, notify: notify})
+	return func() {
+		subsMu.Lock()
+		defer subsMu.Unlock()
+		for i, s := range subs {
+			if s.id == id {
+				subs = append(subs[:i:i], subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Reload 重新加载全部配置层。新配置解析或校验失败时保留当前配置并返回错误。
+func Reload() error {
+	mu.Lock()
+	defer mu.Unlock()
+	return reload(nil)
+}
+
+// reload 重新构建并发布快照，commit 在校验通过、发布之前调用；调用方持有 mu
+func reload(commit func()) error {
+	old := current.Load()
+	if old == nil {
+		return fmt.Errorf("config not loaded")
+	}
+	s, err := build(loadPath)
+	if err != nil {
+		log.Printf("config: reload rejected, keeping last good config: %v", err)
+		return err
+	}
+	if commit != nil {
+		commit()
+	}
+	current.Store(s)
+	saveRemoteCache()
+	log.Printf("config: reloaded from %v", s.files)
+	logChanges(old, s)
+	notify(old.v, s.v)
+	return nil
+}
+
+// notify 通知全部订阅者，单个订阅者 panic 不影响其他订阅者
+func notify(oldV, newV *viper.Viper) {
+	subsMu.Lock()
+	list := append([]subscriber(nil), subs...)
+	subsMu.Unlock()
+
+	for _, s := range list {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("config: subscriber panic: %v", r)
+				}
+			}()
+			s.notify(oldV, newV)
+		}()
+	}
+}
+
+// Watch 监听参与合并的配置文件并轮询远程配置，变化后重新加载。返回停止监听的函数。
+// 监听的是配置文件所在目录：编辑器改名保存、Kubernetes ConfigMap 替换符号链接、
+// 之后新建的环境配置文件都能感知；热更新后新增的文件所在目录同样加入监听。
+func Watch() (stop func()) {
+	s := current.Load()
+	if s == nil {
+		return func() {}
+	}
+	stopPolling := pollRemote()
+
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("config: watch config files: %v", err)
+		return stopPolling
+	}
+	w := &fileWatcher{w: fw, dirs: make(map[string]bool), real: make(map[string]string)}
+	w.add(s.files)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.run()
+	}()
+	return func() {
+		stopPolling()
+		_ = fw.Close()
+		<-done
+		timerMu.Lock()
+		if reloadTimer != nil {
+			reloadTimer.Stop()
+		}
+		timerMu.Unlock()
+	}
+}
+
+// fileWatcher 一个 fsnotify.Watcher 监听全部配置目录，只在 run 的 goroutine 中访问
+type fileWatcher struct {
+	w    *fsnotify.Watcher
+	dirs map[string]bool
+	real map[string]string // 配置文件解析符号链接后的路径
+}
+
+// add 监听 files 所在的目录
+func (w *fileWatcher) add(files []string) {
+	for _, file := range files {
+		file = filepath.Clean(file)
+		if _, ok := w.real[file]; !ok {
+			w.real[file], _ = filepath.EvalSymlinks(file)
+		}
+		dir := filepath.Dir(file)
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.w.Add(dir); err != nil {
+			log.Printf("config: watch %s: %v", dir, err)
+			continue
+		}
+		w.dirs[dir] = true
+	}
+}
+
+// run 处理事件直到 watcher 关闭
+func (w *fileWatcher) run() {
+	for {
+		select {
+		case ev, ok := <-w.w.Events:
+			if !ok {
+				return
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			files := current.Load().files
+			w.add(files)
+			if w.relevant(ev.Name, files) {
+				scheduleReload()
+			}
+		case err, ok := <-w.w.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("config: watch config files: %v", err)
+		}
+	}
+}
+
+// relevant name 是否影响配置：参与合并的文件本身、同目录下新建的 app.*.yaml，
+// 或者同目录的变化使某个配置文件的符号链接指向了新文件
+func (w *fileWatcher) relevant(name string, files []string) bool {
+	name = filepath.Clean(name)
+	changed := false
+	for _, file := range files {
+		file = filepath.Clean(file)
+		if file == name {
+			changed = true
+		}
+		if filepath.Dir(file) != filepath.Dir(name) {
+			continue
+		}
+		if real, _ := filepath.EvalSymlinks(file); real != w.real[file] {
+			w.real[file] = real
+			changed = true
+		}
+		if base := filepath.Base(name); strings.HasPrefix(base, "app.") &&
+			(strings.HasSuffix(base, ".yaml") || strings.HasSuffix(base, ".yml")) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// scheduleReload 延迟 reloadDelay 后重新加载，期间的重复事件只触发一次
+func scheduleReload() {
+	timerMu.Lock()
+	defer timerMu.Unlock()
+	if reloadTimer != nil {
+		reloadTimer.Stop()
+	}
+	reloadTimer = time.AfterFunc(reloadDelay, func() { _ = Reload() })
+}
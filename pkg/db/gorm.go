@@ -5,41 +5,51 @@ import (
 	"fmt"
 	"time"
 
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"go.uber.org/fx"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
-	"gorm.io/plugin/dbresolver"
 
 	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/graceful"
 )
 
+// Config MySQL 配置，对应 mysql 一节
+type Config struct {
+	DSN           string        `mapstructure:"dsn" validate:"required"`
+	Password      string        `mapstructure:"password" comment:"非空时替换 dsn 中的密码，便于单独用 file:/env:/enc: 引用"`
+	MaxOpen       int           `mapstructure:"max_open_conns" validate:"min=0" comment:"连接池最大打开连接数"`
+	MaxIdle       int           `mapstructure:"max_idle_conns" validate:"min=0" comment:"连接池最大空闲连接数"`
+	MaxLifetime   time.Duration `mapstructure:"max_lifetime" validate:"min=0" comment:"连接最大生命周期，0 表示不限制"`
+	SlowThreshold time.Duration `mapstructure:"slow_threshold" validate:"min=0" comment:"慢查询阈值，0 表示不记录慢查询"`
+	LogLevel      string        `mapstructure:"log_level" validate:"omitempty,oneof=silent error warn info" comment:"SQL 日志级别：silent / error / warn / info；info 时全部 SQL 以 debug 级别写入日志"`
+	RedactColumns []string      `mapstructure:"redact_columns" comment:"绑定参数需要脱敏的列，password、token 等敏感列总是脱敏"`
+}
+
+// Section mysql 配置节
+var Section = config.NewSection("mysql", "MySQL 连接与连接池", Config{
+	MaxOpen:       50,
+	MaxIdle:       25,
+	MaxLifetime:   time.Hour,
+	SlowThreshold: 500 * time.Millisecond,
+	LogLevel:      "info",
+})
+
 // DB 封装 *gorm.DB，方便后续扩展
 type DB struct {
 	*gorm.DB
 }
 
 // New 根据配置初始化 GORM，支持读写分离、连接池、慢查询日志
-func New(cfg *config.Config) (*DB, error) {
-	// 统一日志级别
-	var logLevel logger.LogLevel
-	switch cfg.MySQL.LogLevel {
-	case "silent":
-		logLevel = logger.Silent
-	case "error":
-		logLevel = logger.Error
-	case "warn":
-		logLevel = logger.Warn
-	default:
-		logLevel = logger.Info
-	}
+func New(cfg Config) (*DB, error) {
+	// SQL 日志写入 pkg/logger，超过 slow_threshold 的查询按慢查询记录并计数
+	gormCfg := &gorm.Config{Logger: newGormLogger(cfg)}
 
-	// GORM 配置
-	gormCfg := &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+	dsn, err := withPassword(cfg.DSN, cfg.Password)
+	if err != nil {
+		return nil, err
 	}
-
-	db, err := gorm.Open(mysql.Open(cfg.MySQL.DSN), gormCfg)
+	db, err := gorm.Open(mysql.Open(dsn), gormCfg)
 	if err != nil {
 		return nil, fmt.Errorf("gorm open error: %w", err)
 	}
@@ -49,21 +59,32 @@ func New(cfg *config.Config) (*DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	sqlDB.SetMaxOpenConns(cfg.MySQL.MaxOpen)
-	sqlDB.SetMaxIdleConns(cfg.MySQL.MaxIdle)
-	if lt, err := time.ParseDuration(cfg.MySQL.MaxLifetime); err == nil {
-		sqlDB.SetConnMaxLifetime(lt)
-	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpen)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdle)
+	sqlDB.SetConnMaxLifetime(cfg.MaxLifetime)
 
 	// 可选：读写分离（主从）示例，若不需要可删除
 	// _ = db.Use(dbresolver.Register(dbresolver.Config{
-	// 	Sources:  []gorm.Dialector{mysql.Open(cfg.MySQL.DSN)},
+	// 	Sources:  []gorm.Dialector{mysql.Open(cfg.DSN)},
 	// 	Replicas: []gorm.Dialector{mysql.Open("slave dsn")},
 	// }))
 
 	return &DB{db}, nil
 }
 
+// withPassword 用 mysql.password 替换 DSN 中的密码，password 为空时原样返回
+func withPassword(dsn, password string) (string, error) {
+	if password == "" {
+		return dsn, nil
+	}
+	c, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("parse mysql dsn: %w", err)
+	}
+	c.Passwd = password
+	return c.FormatDSN(), nil
+}
+
 /* --------------------------------------------------------------------
    通用 CRUD 封装
 -------------------------------------------------------------------- */
@@ -107,8 +128,8 @@ func (db *DB) Count(ctx context.Context, model interface{}, query string, args .
 
 // Paginate 统一分页查询
 type Page struct {
-	Page  int `json:"page"`  // 第几页，从 1 开始
-	Size  int `json:"size"`  // 每页条数
+	Page  int `json:"page"` // 第几页，从 1 开始
+	Size  int `json:"size"` // 每页条数
 	Total int64
 }
 
@@ -146,6 +167,29 @@ func (db *DB) Transaction(ctx context.Context, fn func(tx *DB) error) error {
 	})
 }
 
+// Ping 探活，供健康检查使用
+func (db *DB) Ping(ctx context.Context) error {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
 
-//  Fx 模块
-var Module = fx.Provide(New)
\ No newline at end of file
+// Close 关闭底层连接池
+func (db *DB) Close() error {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Fx 模块，进程退出时在 close 阶段关闭连接池
+var Module = fx.Options(
+	Section.Provide(),
+	fx.Provide(New),
+	fx.Invoke(func(d *DB, m *graceful.Manager) {
+		m.Register("mysql", graceful.CloserShutdown(d))
+	}),
+)
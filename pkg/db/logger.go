@@ -0,0 +1,290 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+// slowQueries 按表统计慢查询次数，通过运维端口的 /metrics 暴露
+var slowQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "gostar",
+	Subsystem: "db",
+	Name:      "slow_queries_total",
+	Help:      "Number of SQL statements slower than mysql.slow_threshold, by table.",
+}, []string{"table"})
+
+func init() {
+	prometheus.MustRegister(slowQueries)
+}
+
+// gormLogger 把 GORM 日志写入 pkg/logger，带上 ctx 中的请求 ID 等字段。
+// SQL 执行失败记 error，慢查询记 warn，其余 SQL 追踪记 debug。
+type gormLogger struct {
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+	redact        map[string]bool // 额外需要脱敏的列，小写
+}
+
+func newGormLogger(cfg Config) *gormLogger {
+	l := &gormLogger{
+		level:         parseLogLevel(cfg.LogLevel),
+		slowThreshold: cfg.SlowThreshold,
+		redact:        make(map[string]bool, len(cfg.RedactColumns)),
+	}
+	for _, col := range cfg.RedactColumns {
+		l.redact[strings.ToLower(col)] = true
+	}
+	return l
+}
+
+// parseLogLevel silent / error / warn / info（缺省）
+func parseLogLevel(level string) gormlogger.LogLevel {
+	switch level {
+	case "silent":
+		return gormlogger.Silent
+	case "error":
+		return gormlogger.Error
+	case "warn":
+		return gormlogger.Warn
+	default:
+		return gormlogger.Info
+	}
+}
+
+// LogMode 实现 gormlogger.Interface，db.Debug() 等会调用
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	nl := *l
+	nl.level = level
+	return &nl
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		logger.NamedFromContext(ctx, "db").Infof(msg, data...)
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		logger.NamedFromContext(ctx, "db").Warnf(msg, data...)
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		logger.NamedFromContext(ctx, "db").Errorf(msg, data...)
+	}
+}
+
+// Trace 记录一条 SQL；慢查询无论日志级别都会计数。
+// 只有日志会写出（db 模块开启 debug、慢查询或执行失败）时才调用 fc，避免每条 SQL 都走 ParamsFilter 拼接。
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	slow := l.slowThreshold > 0 && elapsed >= l.slowThreshold
+	failed := err != nil && !errors.Is(err, gormlogger.ErrRecordNotFound) && l.level >= gormlogger.Error
+	debug := l.level >= gormlogger.Info && logger.Named("db").Logger.IsLevelEnabled(logrus.DebugLevel)
+	if !slow && !failed && !debug {
+		return
+	}
+
+	sql, rows := fc()
+	table := tableName(sql)
+	if slow {
+		slowQueries.WithLabelValues(table).Inc()
+	}
+
+	entry := func() *logrus.Entry {
+		e := logger.NamedFromContext(ctx, "db").WithFields(logrus.Fields{
+			"sql":        sql,
+			"table":      table,
+			"latency_ms": float64(elapsed.Microseconds()) / 1000,
+			"caller":     caller(),
+		})
+		if rows >= 0 {
+			e = e.WithField("rows", rows)
+		}
+		return e
+	}
+	switch {
+	case failed:
+		entry().WithError(err).Error("sql error")
+	case slow && l.level >= gormlogger.Warn:
+		entry().WithField("slow_threshold", l.slowThreshold.String()).Warn("slow sql")
+	case debug:
+		entry().Debug("sql")
+	}
+}
+
+// ParamsFilter 实现 gorm.ParamsFilter：敏感列的绑定参数在写入日志前替换为 config.Mask
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	cols := placeholderColumns(sql)
+	out := make([]interface{}, len(params))
+	for i, p := range params {
+		out[i] = p
+		if i < len(cols) && cols[i] != "" && (config.IsSensitive(cols[i]) || l.redact[cols[i]]) {
+			out[i] = config.Mask
+		}
+	}
+	return sql, out
+}
+
+var (
+	// insertColumns INSERT INTO `t` (`a`,`b`) VALUES (?,?),(?,?)
+	insertColumns = regexp.MustCompile("(?is)^\\s*INSERT\\s+INTO\\s+\\S+\\s*\\(([^)]*)\\)\\s*VALUES")
+	// tableRef FROM / INTO / UPDATE 之后的第一个表名
+	tableRef = regexp.MustCompile("(?i)\\b(?:FROM|INTO|UPDATE)\\s+([\\w`\".]+)")
+)
+
+// placeholderColumns 扫描 SQL 状态
+const (
+	scanNone   = iota
+	scanColumn // 刚读到列名
+	scanOp     // 列名 =、<>、LIKE 等之后
+	scanIn     // 列名 IN 之后，等待 (
+	scanInList // 列名 IN ( 之后，可以是 ?, ?, ...
+)
+
+// placeholderColumns 按顺序返回每个 ? 占位符对应的列名（小写），无法判断时为空。
+// 一次线性扫描，记录最近的列名以及它之后出现的比较符：`col` = ?、t.col LIKE ?、col IN (?, ?)。
+func placeholderColumns(sql string) []string {
+	var cols []string
+	var insert []string
+	valuesAt := -1
+	if m := insertColumns.FindStringSubmatchIndex(sql); m != nil {
+		for _, c := range strings.Split(sql[m[2]:m[3]], ",") {
+			insert = append(insert, columnName(c))
+		}
+		valuesAt = m[1]
+	}
+
+	n := 0 // VALUES 中已出现的占位符数
+	col, state := "", scanNone
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case ch == '\'':
+			// 跳过字符串字面量，其中的 ? 不是占位符
+			i++
+			for i < len(sql) && sql[i] != '\'' {
+				if sql[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+			state = scanNone
+			continue
+		case ch == '?':
+			c := ""
+			if valuesAt >= 0 && i > valuesAt && len(insert) > 0 {
+				c = insert[n%len(insert)]
+				n++
+			} else if state == scanOp || state == scanInList {
+				c = col
+			}
+			cols = append(cols, c)
+			if state != scanInList {
+				state = scanNone
+			}
+		case isIdentChar(ch):
+			j := i
+			for j < len(sql) && isIdentChar(sql[j]) {
+				j++
+			}
+			word := sql[i:j]
+			switch {
+			case state == scanColumn && strings.EqualFold(word, "NOT"):
+			case state == scanColumn && strings.EqualFold(word, "LIKE"):
+				state = scanOp
+			case state == scanColumn && strings.EqualFold(word, "IN"):
+				state = scanIn
+			default:
+				col, state = columnName(word), scanColumn
+			}
+			i = j
+			continue
+		case strings.IndexByte("=<>!", ch) >= 0:
+			for i < len(sql) && strings.IndexByte("=<>!", sql[i]) >= 0 {
+				i++
+			}
+			if state == scanColumn {
+				state = scanOp
+			} else {
+				state = scanNone
+			}
+			continue
+		case ch == '(':
+			if state == scanIn {
+				state = scanInList
+			} else {
+				state = scanNone
+			}
+		case ch == ',':
+			if state != scanInList {
+				state = scanNone
+			}
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+		default:
+			state = scanNone
+		}
+		i++
+	}
+	return cols
+}
+
+// isIdentChar 列名中的字符：字母、数字、下划线、引号和表名分隔的点
+func isIdentChar(ch byte) bool {
+	return ch == '_' || ch == '.' || ch == '`' || ch == '"' ||
+		('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || ('0' <= ch && ch <= '9')
+}
+
+// tableName 取 SQL 中的表名，用作慢查询指标的标签
+func tableName(sql string) string {
+	if m := tableRef.FindStringSubmatch(sql); m != nil {
+		return columnName(m[1])
+	}
+	return "unknown"
+}
+
+// columnName 去掉引号和表名前缀：`users`.`password` -> password
+func columnName(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndex(s, "."); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.ToLower(strings.Trim(s, "`\""))
+}
+
+// pkgPath 本包路径，查找调用位置时跳过
+var pkgPath = reflect.TypeOf(gormLogger{}).PkgPath()
+
+// caller 跳过 gorm 和本包封装，返回发起查询的业务代码位置
+func caller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		inLib := strings.HasPrefix(f.Function, "gorm.io/") ||
+			(strings.HasPrefix(f.Function, pkgPath+".") && !strings.HasSuffix(f.File, "_test.go"))
+		if !inLib && f.File != "" {
+			return f.File + ":" + strconv.Itoa(f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+type account struct {
+	ID       uint
+	Username string
+	Password string
+	Phone    string
+}
+
+func TestGormLogger(t *testing.T) {
+	Convey("GORM 日志桥接", t, func() {
+		var buf bytes.Buffer
+		logger.L = logrus.New()
+		logger.L.SetOutput(&buf)
+		logger.L.SetFormatter(&logrus.JSONFormatter{})
+		So(logger.SetLevel("debug"), ShouldBeNil)
+
+		entries := func() []map[string]interface{} {
+			var out []map[string]interface{}
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				if line == "" {
+					continue
+				}
+				var e map[string]interface{}
+				So(json.Unmarshal([]byte(line), &e), ShouldBeNil)
+				out = append(out, e)
+			}
+			return out
+		}
+
+		l := newGormLogger(Config{LogLevel: "info", SlowThreshold: 100 * time.Millisecond, RedactColumns: []string{"Phone"}})
+		ctx := logger.WithContext(context.Background(), logrus.Fields{logger.FieldRequestID: "req-1"})
+
+		Convey("占位符对应的列", func() {
+			So(placeholderColumns("INSERT INTO `accounts` (`username`,`password`) VALUES (?,?),(?,?)"),
+				ShouldResemble, []string{"username", "password", "username", "password"})
+			So(placeholderColumns("UPDATE `accounts` SET `password`=?,`updated_at`=? WHERE `accounts`.`id` = ? AND name LIKE ?"),
+				ShouldResemble, []string{"password", "updated_at", "id", "name"})
+			So(placeholderColumns("SELECT * FROM t WHERE token IN (?,?) AND note = 'a?b' AND ? > 1"),
+				ShouldResemble, []string{"token", "token", ""})
+			So(placeholderColumns("SELECT * FROM t WHERE `t`.`mobile` NOT IN (?, ?) AND a = b AND c <> ?"),
+				ShouldResemble, []string{"mobile", "mobile", "c"})
+
+			// 一次线性扫描，长 IN 列表不会随占位符数平方增长
+			sql := "SELECT * FROM t WHERE id IN (?" + strings.Repeat(",?", 19999) + ")"
+			start := time.Now()
+			cols := placeholderColumns(sql)
+			So(time.Since(start), ShouldBeLessThan, time.Second)
+			So(cols, ShouldHaveLength, 20000)
+			So(cols[19999], ShouldEqual, "id")
+		})
+
+		Convey("SQL 不会写出时不调用 fc", func() {
+			So(logger.SetLevel("info"), ShouldBeNil)
+			called := false
+			l.Trace(ctx, time.Now(), func() (string, int64) {
+				called = true
+				return "SELECT 1", 1
+			}, nil)
+			So(called, ShouldBeFalse)
+			So(buf.Len(), ShouldEqual, 0)
+		})
+
+		Convey("SQL 带请求 ID 写入日志，敏感列的参数脱敏", func() {
+			db, err := gorm.Open(mysql.New(mysql.Config{DSN: "u:p@tcp(127.0.0.1:1)/x", SkipInitializeWithVersion: true}),
+				&gorm.Config{Logger: l, DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+			So(err, ShouldBeNil)
+			So(db.WithContext(ctx).Create(&account{Username: "alice", Password: "p@ss", Phone: "13800000000"}).Error, ShouldBeNil)
+
+			es := entries()
+			So(es, ShouldHaveLength, 1)
+			e := es[0]
+			So(e["level"], ShouldEqual, "debug")
+			So(e["msg"], ShouldEqual, "sql")
+			So(e["table"], ShouldEqual, "accounts")
+			So(e[logger.FieldRequestID], ShouldEqual, "req-1")
+			So(e["sql"], ShouldContainSubstring, "'alice'")
+			So(e["sql"], ShouldContainSubstring, "'"+config.Mask+"'")
+			So(e["sql"], ShouldNotContainSubstring, "p@ss")
+			So(e["sql"], ShouldNotContainSubstring, "13800000000")
+			So(e["caller"], ShouldContainSubstring, "logger_test.go")
+		})
+
+		Convey("慢查询记 warn 并按表计数", func() {
+			before := testutil.ToFloat64(slowQueries.WithLabelValues("accounts"))
+			l.Trace(ctx, time.Now().Add(-time.Second), func() (string, int64) {
+				return "SELECT * FROM `accounts` WHERE id = 1", 1
+			}, nil)
+			So(testutil.ToFloat64(slowQueries.WithLabelValues("accounts")), ShouldEqual, before+1)
+
+			es := entries()
+			So(es, ShouldHaveLength, 1)
+			So(es[0]["level"], ShouldEqual, "warning")
+			So(es[0]["msg"], ShouldEqual, "slow sql")
+			So(es[0]["rows"], ShouldEqual, 1)
+		})
+
+		Convey("执行失败记 error，记录不存在不记", func() {
+			sql := func() (string, int64) { return "SELECT * FROM `accounts`", 0 }
+			l.Trace(ctx, time.Now(), sql, gormlogger.ErrRecordNotFound)
+			l.LogMode(gormlogger.Error).Trace(ctx, time.Now(), sql, errors.New("boom"))
+			l.LogMode(gormlogger.Error).Trace(ctx, time.Now(), sql, nil)
+
+			es := entries()
+			So(es, ShouldHaveLength, 2)
+			So(es[0]["msg"], ShouldEqual, "sql")
+			So(es[1]["level"], ShouldEqual, "error")
+			So(es[1]["error"], ShouldEqual, "boom")
+		})
+	})
+}
@@ -0,0 +1,285 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// DefaultTimeout 整体关闭超时
+const DefaultTimeout = 30 * time.Second
+
+// Phase 关闭阶段，按从小到大的顺序依次执行
+type Phase int
+
+const (
+	// PhaseStopAccepting 停止接收新流量（例如就绪探针置为失败）
+	PhaseStopAccepting Phase = iota
+	// PhaseDrain 排空处理中的请求和任务（HTTP Server、后台 worker）
+	PhaseDrain
+	// PhaseClose 关闭外部资源（DB、Redis 等）
+	PhaseClose
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseStopAccepting:
+		return "stop-accepting"
+	case PhaseDrain:
+		return "drain"
+	case PhaseClose:
+		return "close"
+	default:
+		return fmt.Sprintf("phase-%d", int(p))
+	}
+}
+
+// Hook 一个关闭动作
+type Hook struct {
+	Phase   Phase
+	Timeout time.Duration // 单个 hook 的超时，0 表示只受整体超时约束
+	Fn      func(ctx context.Context) error
+}
+
+// WithPhase 返回指定阶段的 hook 副本
+func (h Hook) WithPhase(p Phase) Hook {
+	h.Phase = p
+	return h
+}
+
+// WithTimeout 返回指定超时的 hook 副本
+func (h Hook) WithTimeout(d time.Duration) Hook {
+	h.Timeout = d
+	return h
+}
+
+type namedHook struct {
+	name string
+	Hook
+}
+
+// Manager 管理关闭 hook，捕获 SIGINT/SIGTERM 后按阶段执行
+type Manager struct {
+	mu      sync.Mutex
+	hooks   []namedHook
+	timeout time.Duration
+
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+// NewManager 创建关闭管理器
+func NewManager() *Manager {
+	return &Manager{
+		timeout: DefaultTimeout,
+		done:    make(chan struct{}),
+	}
+}
+
+// SetTimeout 设置整体关闭超时
+func (m *Manager) SetTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d > 0 {
+		m.timeout = d
+	}
+}
+
+// Register 注册一个具名 hook，同名 hook 会被覆盖
+func (m *Manager) Register(name string, h Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.hooks {
+		if m.hooks[i].name == name {
+			m.hooks[i].Hook = h
+			return
+		}
+	}
+	m.hooks = append(m.hooks, namedHook{name: name, Hook: h})
+}
+
+// Wait 阻塞等待 SIGINT/SIGTERM，然后执行关闭；Shutdown 被其他地方调用时也会返回
+func (m *Manager) Wait() error {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	select {
+	case sig := <-quit:
+		log.Printf("graceful: received signal %s, shutting down", sig)
+	case <-m.done:
+		return m.err
+	}
+
+	m.mu.Lock()
+	timeout := m.timeout
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := m.Shutdown(ctx)
+	if err != nil {
+		log.Printf("graceful: shutdown finished with errors: %v", err)
+	}
+	return err
+}
+
+// Shutdown 按阶段执行所有 hook：同一阶段内并发，阶段之间串行。
+// 只会执行一次，重复调用返回第一次的结果。
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.once.Do(func() {
+		m.err = m.run(ctx)
+		close(m.done)
+	})
+	<-m.done
+	return m.err
+}
+
+// Done 关闭完成后被关闭的 channel
+func (m *Manager) Done() <-chan struct{} {
+	return m.done
+}
+
+func (m *Manager) run(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := make([]namedHook, len(m.hooks))
+	copy(hooks, m.hooks)
+	timeout := m.timeout
+	m.mu.Unlock()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// 稳定排序，保证同阶段内按注册顺序启动
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].Phase < hooks[j].Phase })
+
+	var errs []error
+	for start := 0; start < len(hooks); {
+		end := start
+		for end < len(hooks) && hooks[end].Phase == hooks[start].Phase {
+			end++
+		}
+		errs = append(errs, runPhase(ctx, hooks[start:end])...)
+		start = end
+	}
+	return errors.Join(errs...)
+}
+
+// runPhase 并发执行同一阶段的 hook，收集错误
+func runPhase(ctx context.Context, hooks []namedHook) []error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, h := range hooks {
+		wg.Add(1)
+		go func(h namedHook) {
+			defer wg.Done()
+			if err := runHook(ctx, h); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+				mu.Unlock()
+			}
+		}(h)
+	}
+	wg.Wait()
+	return errs
+}
+
+// runHook 执行单个 hook，超时后不再等待其返回
+func runHook(ctx context.Context, h namedHook) (err error) {
+	if h.Fn == nil {
+		return nil
+	}
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		result <- h.Fn(ctx)
+	}()
+
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		log.Printf("graceful: [%s] %s failed after %s: %v", h.Phase, h.name, time.Since(start), err)
+	} else {
+		log.Printf("graceful: [%s] %s done in %s", h.Phase, h.name, time.Since(start))
+	}
+	return err
+}
+
+// ---------------- 常用 Hook ----------------
+
+// HTTPServerShutdown 停止 http.Server 并等待处理中的请求完成
+func HTTPServerShutdown(srv *http.Server) Hook {
+	return Hook{
+		Phase: PhaseDrain,
+		Fn: func(ctx context.Context) error {
+			if err := srv.Shutdown(ctx); err != nil {
+				_ = srv.Close()
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// CloserShutdown 关闭 DB、Redis 等资源
+func CloserShutdown(c io.Closer) Hook {
+	return Hook{
+		Phase: PhaseClose,
+		Fn: func(ctx context.Context) error {
+			return c.Close()
+		},
+	}
+}
+
+// CustomShutdown 自定义关闭逻辑，默认在 PhaseClose 阶段执行
+func CustomShutdown(fn func(ctx context.Context) error) Hook {
+	return Hook{Phase: PhaseClose, Fn: fn}
+}
+
+// ---------------- Fx 适配 ----------------
+
+// Attach 把管理器挂到 fx 生命周期上，fx 停止时执行全部 hook。
+// fx 自己处理信号，此时不需要再调用 Wait。
+func (m *Manager) Attach(lc fx.Lifecycle) {
+	lc.Append(fx.Hook{
+		OnStop: m.Shutdown,
+	})
+}
+
+// Module 提供 *Manager 并挂到 fx 生命周期
+var Module = fx.Options(
+	fx.Provide(NewManager),
+	fx.Invoke(func(lc fx.Lifecycle, m *Manager) { m.Attach(lc) }),
+)
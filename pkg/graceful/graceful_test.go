@@ -0,0 +1,94 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestManager(t *testing.T) {
+	Convey("关闭管理器测试", t, func() {
+		m := NewManager()
+
+		Convey("按阶段顺序执行", func() {
+			var (
+				mu    sync.Mutex
+				order []string
+			)
+			record := func(name string) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					mu.Lock()
+					order = append(order, name)
+					mu.Unlock()
+					return nil
+				}
+			}
+			m.Register("db", Hook{Phase: PhaseClose, Fn: record("db")})
+			m.Register("http", Hook{Phase: PhaseDrain, Fn: record("http")})
+			m.Register("ready", Hook{Phase: PhaseStopAccepting, Fn: record("ready")})
+
+			So(m.Shutdown(context.Background()), ShouldBeNil)
+			So(order, ShouldResemble, []string{"ready", "http", "db"})
+		})
+
+		Convey("聚合多个错误", func() {
+			m.Register("a", CustomShutdown(func(ctx context.Context) error { return errors.New("boom a") }))
+			m.Register("b", CustomShutdown(func(ctx context.Context) error { return errors.New("boom b") }))
+
+			err := m.Shutdown(context.Background())
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "a: boom a")
+			So(err.Error(), ShouldContainSubstring, "b: boom b")
+		})
+
+		Convey("单个 hook 超时不阻塞后续阶段", func() {
+			closed := false
+			m.Register("slow", Hook{
+				Phase:   PhaseDrain,
+				Timeout: 20 * time.Millisecond,
+				Fn: func(ctx context.Context) error {
+					time.Sleep(time.Second)
+					return nil
+				},
+			})
+			m.Register("db", CustomShutdown(func(ctx context.Context) error {
+				closed = true
+				return nil
+			}))
+
+			start := time.Now()
+			err := m.Shutdown(context.Background())
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			So(closed, ShouldBeTrue)
+			So(time.Since(start), ShouldBeLessThan, 500*time.Millisecond)
+		})
+
+		Convey("panic 转换为错误", func() {
+			m.Register("panic", CustomShutdown(func(ctx context.Context) error { panic("oops") }))
+			err := m.Shutdown(context.Background())
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "panic: oops")
+		})
+
+		Convey("只执行一次", func() {
+			calls := 0
+			m.Register("once", CustomShutdown(func(ctx context.Context) error {
+				calls++
+				return nil
+			}))
+			So(m.Shutdown(context.Background()), ShouldBeNil)
+			So(m.Shutdown(context.Background()), ShouldBeNil)
+			So(calls, ShouldEqual, 1)
+
+			select {
+			case <-m.Done():
+			default:
+				t.Fatal("Done should be closed after Shutdown")
+			}
+		})
+	})
+}
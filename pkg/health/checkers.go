@@ -0,0 +1,50 @@
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// SlowThreshold ping 超过该耗时记为 degraded
+const SlowThreshold = time.Second
+
+// PingFunc 依赖探活函数，如 (*db.DB).Ping、(*redis.Client).Ping
+type PingFunc func(ctx context.Context) error
+
+// NewPingChecker 通用探活检查：失败 unhealthy，过慢 degraded
+func NewPingChecker(component string, ping PingFunc) Checker {
+	return CheckFunc(func(ctx context.Context) CheckResult {
+		start := time.Now()
+		err := ping(ctx)
+		latency := time.Since(start)
+
+		res := CheckResult{
+			Status:    StatusHealthy,
+			Message:   component + " is reachable",
+			Timestamp: time.Now(),
+			Details: map[string]interface{}{
+				"component": component,
+				"latency":   latency.String(),
+			},
+		}
+		switch {
+		case err != nil:
+			res.Status = StatusUnhealthy
+			res.Message = component + " ping failed: " + err.Error()
+		case latency > SlowThreshold:
+			res.Status = StatusDegraded
+			res.Message = component + " responds slowly"
+		}
+		return res
+	})
+}
+
+// NewDatabaseChecker 数据库检查
+func NewDatabaseChecker(name string, ping PingFunc) Checker {
+	return NewPingChecker(name, ping)
+}
+
+// NewRedisChecker Redis 检查
+func NewRedisChecker(name string, ping PingFunc) Checker {
+	return NewPingChecker(name, ping)
+}
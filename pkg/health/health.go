@@ -0,0 +1,379 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/graceful"
+)
+
+// 缺省参数
+const (
+	DefaultTimeout  = 5 * time.Second
+	DefaultCacheTTL = 2 * time.Second
+)
+
+// Config 健康检查配置，对应 health 一节
+type Config struct {
+	ShutdownDelay time.Duration `mapstructure:"shutdown_delay" validate:"min=0" comment:"关闭时就绪探针置为失败后，等待该时长再排空连接，让负载均衡和 Kubernetes 先摘除实例；计入 server.shutdown_timeout"`
+}
+
+// Section health 配置节
+var Section = config.NewSection("health", "健康检查", Config{ShutdownDelay: 5 * time.Second})
+
+// Status 健康状态
+type Status string
+
+const (
+	StatusHealthy   Status = "healthy"
+	StatusDegraded  Status = "degraded"
+	StatusUnhealthy Status = "unhealthy"
+)
+
+// CheckResult 单项检查结果
+type CheckResult struct {
+	Name      string                 `json:"name"`
+	Status    Status                 `json:"status"`
+	Message   string                 `json:"message,omitempty"`
+	Critical  bool                   `json:"critical"`
+	Duration  string                 `json:"duration,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// Report 汇总结果
+type Report struct {
+	Status    Status                 `json:"status"`
+	Service   string                 `json:"service"`
+	Version   string                 `json:"version"`
+	Uptime    string                 `json:"uptime"`
+	Timestamp time.Time              `json:"timestamp"`
+	Checks    map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Checker 检查器接口
+type Checker interface {
+	Check(ctx context.Context) CheckResult
+}
+
+// CheckFunc 函数形式的检查器
+type CheckFunc func(ctx context.Context) CheckResult
+
+// Check 实现 Checker
+func (f CheckFunc) Check(ctx context.Context) CheckResult {
+	return f(ctx)
+}
+
+// Option 注册检查项时的可选参数
+type Option func(*entry)
+
+// NonCritical 非关键检查：失败时整体状态为 degraded 而不是 unhealthy
+func NonCritical() Option {
+	return func(e *entry) { e.critical = false }
+}
+
+type entry struct {
+	name     string
+	checker  Checker
+	critical bool
+}
+
+// Manager 管理所有检查项，并行执行、缓存结果
+type Manager struct {
+	service string
+	version string
+	started time.Time
+
+	mu       sync.RWMutex
+	entries  []*entry
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	shutdownDelay time.Duration
+
+	runMu    sync.Mutex // 防止缓存失效瞬间并发重复检查
+	cacheMu  sync.RWMutex
+	cached   *Report
+	cachedAt time.Time
+
+	ready atomic.Bool
+}
+
+// NewManager 创建健康检查管理器
+func NewManager(service, version string) *Manager {
+	m := &Manager{
+		service:  service,
+		version:  version,
+		started:  time.Now(),
+		timeout:  DefaultTimeout,
+		cacheTTL: DefaultCacheTTL,
+	}
+	m.ready.Store(true)
+	return m
+}
+
+// SetTimeout 设置单轮检查的超时
+func (m *Manager) SetTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timeout = d
+}
+
+// SetCacheTTL 设置结果缓存时间，0 表示不缓存
+func (m *Manager) SetCacheTTL(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheTTL = d
+}
+
+// SetShutdownDelay 设置关闭时就绪探针置为失败后的等待时长，0 表示不等待
+func (m *Manager) SetShutdownDelay(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shutdownDelay = d
+}
+
+// Register 注册检查项，默认为关键检查；同名检查项会被覆盖
+func (m *Manager) Register(name string, c Checker, opts ...Option) {
+	e := &entry{name: name, checker: c, critical: true}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.entries {
+		if m.entries[i].name == name {
+			m.entries[i] = e
+			m.invalidate()
+			return
+		}
+	}
+	m.entries = append(m.entries, e)
+	m.invalidate()
+}
+
+// RegisterFunc 以函数形式注册检查项
+func (m *Manager) RegisterFunc(name string, fn CheckFunc, opts ...Option) {
+	m.Register(name, fn, opts...)
+}
+
+// Unregister 移除检查项
+func (m *Manager) Unregister(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.entries {
+		if m.entries[i].name == name {
+			m.entries = append(m.entries[:i], m.entries[i+1:]...)
+			m.invalidate()
+			return
+		}
+	}
+}
+
+// SetReady 设置就绪状态，关闭流程开始时置为 false 让负载均衡摘除流量
+func (m *Manager) SetReady(ready bool) {
+	m.ready.Store(ready)
+}
+
+// Ready 当前是否就绪
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// Check 返回汇总结果，缓存未过期时直接返回缓存
+func (m *Manager) Check(ctx context.Context) Report {
+	if r, ok := m.fromCache(); ok {
+		return r
+	}
+
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+	// 等锁期间可能已有其他请求刷新了缓存
+	if r, ok := m.fromCache(); ok {
+		return r
+	}
+
+	r := m.run(ctx)
+	m.cacheMu.Lock()
+	m.cached, m.cachedAt = &r, time.Now()
+	m.cacheMu.Unlock()
+	return r
+}
+
+func (m *Manager) fromCache() (Report, bool) {
+	m.mu.RLock()
+	ttl := m.cacheTTL
+	m.mu.RUnlock()
+
+	m.cacheMu.RLock()
+	defer m.cacheMu.RUnlock()
+	if m.cached == nil || ttl <= 0 || time.Since(m.cachedAt) > ttl {
+		return Report{}, false
+	}
+	return *m.cached, true
+}
+
+// invalidate 清空缓存，调用方需持有 m.mu
+func (m *Manager) invalidate() {
+	m.cacheMu.Lock()
+	m.cached = nil
+	m.cacheMu.Unlock()
+}
+
+// run 在超时内并行执行所有检查
+func (m *Manager) run(ctx context.Context) Report {
+	m.mu.RLock()
+	entries := make([]*entry, len(m.entries))
+	copy(entries, m.entries)
+	timeout := m.timeout
+	m.mu.RUnlock()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	results := make([]CheckResult, len(entries))
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, e)
+		}(i, e)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    StatusHealthy,
+		Service:   m.service,
+		Version:   m.version,
+		Uptime:    time.Since(m.started).Round(time.Second).String(),
+		Timestamp: time.Now(),
+		Checks:    make(map[string]CheckResult, len(results)),
+	}
+	for _, r := range results {
+		report.Checks[r.Name] = r
+		report.Status = aggregate(report.Status, r)
+	}
+	return report
+}
+
+// runCheck 执行单个检查，超时或 panic 均视为 unhealthy
+func runCheck(ctx context.Context, e *entry) CheckResult {
+	start := time.Now()
+	done := make(chan CheckResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- CheckResult{Status: StatusUnhealthy, Message: fmt.Sprintf("panic: %v", r)}
+			}
+		}()
+		done <- e.checker.Check(ctx)
+	}()
+
+	var res CheckResult
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res = CheckResult{Status: StatusUnhealthy, Message: "check timed out: " + ctx.Err().Error()}
+	}
+
+	res.Name = e.name
+	res.Critical = e.critical
+	res.Duration = time.Since(start).String()
+	if res.Status == "" {
+		res.Status = StatusHealthy
+	}
+	if res.Timestamp.IsZero() {
+		res.Timestamp = time.Now()
+	}
+	return res
+}
+
+// aggregate 关键检查失败 -> unhealthy；非关键检查失败或任意 degraded -> degraded
+func aggregate(current Status, r CheckResult) Status {
+	switch {
+	case r.Status == StatusUnhealthy && r.Critical:
+		return StatusUnhealthy
+	case current == StatusUnhealthy:
+		return current
+	case r.Status != StatusHealthy:
+		return StatusDegraded
+	default:
+		return current
+	}
+}
+
+// ---------------- HTTP 处理器 ----------------
+
+// HTTPHandler 完整健康报告，unhealthy 时返回 503
+func (m *Manager) HTTPHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := m.Check(c.Request.Context())
+		c.JSON(statusCode(r.Status), r)
+	}
+}
+
+// ReadinessHandler 就绪探针：未就绪或关键依赖不可用时返回 503
+func (m *Manager) ReadinessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.Ready() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": StatusUnhealthy, "message": "not ready"})
+			return
+		}
+		r := m.Check(c.Request.Context())
+		c.JSON(statusCode(r.Status), gin.H{"status": r.Status})
+	}
+}
+
+// LivenessHandler 存活探针：进程能响应即视为存活，不检查外部依赖
+func (m *Manager) LivenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status": StatusHealthy,
+			"uptime": time.Since(m.started).Round(time.Second).String(),
+		})
+	}
+}
+
+func statusCode(s Status) int {
+	if s == StatusUnhealthy {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// ShutdownHook 关闭时首先把就绪探针置为失败，等待 shutdown delay 后才进入排空阶段，
+// 期间照常处理请求，负载均衡和 endpoints 控制器有时间发现实例未就绪并摘除
+func (m *Manager) ShutdownHook() graceful.Hook {
+	return graceful.Hook{
+		Phase: graceful.PhaseStopAccepting,
+		Fn: func(ctx context.Context) error {
+			m.SetReady(false)
+			m.mu.RLock()
+			delay := m.shutdownDelay
+			m.mu.RUnlock()
+			if delay <= 0 {
+				return nil
+			}
+			t := time.NewTimer(delay)
+			defer t.Stop()
+			select {
+			case <-t.C:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
@@ -0,0 +1,147 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func healthy(ctx context.Context) CheckResult {
+	return CheckResult{Status: StatusHealthy}
+}
+
+func unhealthy(ctx context.Context) CheckResult {
+	return CheckResult{Status: StatusUnhealthy, Message: "down"}
+}
+
+func TestManager(t *testing.T) {
+	Convey("健康检查管理器测试", t, func() {
+		m := NewManager("go-star", "test")
+		m.SetCacheTTL(0)
+
+		Convey("全部通过为 healthy", func() {
+			m.RegisterFunc("a", healthy)
+			m.RegisterFunc("b", healthy)
+
+			r := m.Check(context.Background())
+			So(r.Status, ShouldEqual, StatusHealthy)
+			So(len(r.Checks), ShouldEqual, 2)
+			So(r.Checks["a"].Critical, ShouldBeTrue)
+		})
+
+		Convey("非关键检查失败为 degraded", func() {
+			m.RegisterFunc("a", healthy)
+			m.RegisterFunc("cache", unhealthy, NonCritical())
+
+			r := m.Check(context.Background())
+			So(r.Status, ShouldEqual, StatusDegraded)
+			So(r.Checks["cache"].Critical, ShouldBeFalse)
+		})
+
+		Convey("关键检查失败为 unhealthy", func() {
+			m.RegisterFunc("cache", unhealthy, NonCritical())
+			m.RegisterFunc("db", unhealthy)
+
+			So(m.Check(context.Background()).Status, ShouldEqual, StatusUnhealthy)
+		})
+
+		Convey("检查超时视为 unhealthy", func() {
+			m.SetTimeout(20 * time.Millisecond)
+			m.RegisterFunc("slow", func(ctx context.Context) CheckResult {
+				time.Sleep(time.Second)
+				return CheckResult{Status: StatusHealthy}
+			})
+
+			start := time.Now()
+			r := m.Check(context.Background())
+			So(r.Status, ShouldEqual, StatusUnhealthy)
+			So(r.Checks["slow"].Message, ShouldContainSubstring, "timed out")
+			So(time.Since(start), ShouldBeLessThan, 500*time.Millisecond)
+		})
+
+		Convey("TTL 内使用缓存结果", func() {
+			var calls int32
+			m.SetCacheTTL(time.Minute)
+			m.RegisterFunc("count", func(ctx context.Context) CheckResult {
+				atomic.AddInt32(&calls, 1)
+				return CheckResult{Status: StatusHealthy}
+			})
+
+			m.Check(context.Background())
+			m.Check(context.Background())
+			So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+
+			// 注册新检查项会使缓存失效
+			m.RegisterFunc("other", healthy)
+			m.Check(context.Background())
+			So(atomic.LoadInt32(&calls), ShouldEqual, 2)
+		})
+
+		Convey("Ping 检查器", func() {
+			m.Register("db", NewDatabaseChecker("db", func(ctx context.Context) error { return errors.New("refused") }))
+
+			r := m.Check(context.Background())
+			So(r.Status, ShouldEqual, StatusUnhealthy)
+			So(r.Checks["db"].Message, ShouldContainSubstring, "refused")
+		})
+	})
+}
+
+func TestHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	Convey("探针处理器测试", t, func() {
+		m := NewManager("go-star", "test")
+		m.SetCacheTTL(0)
+		r := gin.New()
+		r.GET("/health", m.HTTPHandler())
+		r.GET("/health/ready", m.ReadinessHandler())
+		r.GET("/health/live", m.LivenessHandler())
+
+		get := func(path string) int {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			return w.Code
+		}
+
+		Convey("依赖故障时 health/ready 返回 503，live 仍为 200", func() {
+			m.RegisterFunc("db", unhealthy)
+			So(get("/health"), ShouldEqual, http.StatusServiceUnavailable)
+			So(get("/health/ready"), ShouldEqual, http.StatusServiceUnavailable)
+			So(get("/health/live"), ShouldEqual, http.StatusOK)
+		})
+
+		Convey("关闭阶段就绪探针返回 503", func() {
+			m.RegisterFunc("db", healthy)
+			So(get("/health/ready"), ShouldEqual, http.StatusOK)
+
+			So(m.ShutdownHook().Fn(context.Background()), ShouldBeNil)
+			So(get("/health/ready"), ShouldEqual, http.StatusServiceUnavailable)
+			So(get("/health"), ShouldEqual, http.StatusOK)
+		})
+
+		Convey("就绪探针失败后等待 shutdown delay 再进入排空阶段", func() {
+			m.SetShutdownDelay(100 * time.Millisecond)
+			done := make(chan error, 1)
+			start := time.Now()
+			go func() { done <- m.ShutdownHook().Fn(context.Background()) }()
+
+			time.Sleep(20 * time.Millisecond)
+			So(get("/health/ready"), ShouldEqual, http.StatusServiceUnavailable)
+			So(<-done, ShouldBeNil)
+			So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 100*time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			m.SetShutdownDelay(time.Hour)
+			So(m.ShutdownHook().Fn(ctx), ShouldEqual, context.DeadlineExceeded)
+		})
+	})
+}
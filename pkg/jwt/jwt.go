@@ -1,13 +1,23 @@
-
 package jwt
 
 import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/fx"
+
 	"github.com/jiujuan/go-star/pkg/config"
 )
 
+// Config JWT 配置，对应 jwt 一节
+type Config struct {
+	Secret string        `mapstructure:"secret" validate:"required,min=32" comment:"签名密钥，至少 32 字节，建议用 file:/env:/enc: 引用"`
+	Expire time.Duration `mapstructure:"expire" validate:"gt=0" comment:"token 有效期"`
+}
+
+// Section jwt 配置节
+var Section = config.NewSection("jwt", "JWT 签发与校验", Config{Expire: 24 * time.Hour})
+
 type Manager struct {
 	secret []byte
 	expire time.Duration
@@ -18,11 +28,11 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-func New(cfg *config.Config) *Manager {
-	d, _ := time.ParseDuration(cfg.JWT.Expire)
+// New 创建 token 管理器，secret 和 expire 已在加载配置时校验
+func New(cfg Config) *Manager {
 	return &Manager{
-		secret: []byte(cfg.JWT.Secret),
-		expire: d,
+		secret: []byte(cfg.Secret),
+		expire: cfg.Expire,
 	}
 }
 
@@ -50,4 +60,4 @@ func (m *Manager) Parse(tokenStr string) (*Claims, error) {
 	return nil, jwt.ErrTokenInvalidClaims
 }
 
-var Module = fx.Provide(New)
\ No newline at end of file
+var Module = fx.Options(Section.Provide(), fx.Provide(New))
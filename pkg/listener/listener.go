@@ -0,0 +1,220 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 继承监听 fd 使用的环境变量
+const (
+	// systemd socket activation
+	envSystemdPID   = "LISTEN_PID"
+	envSystemdFDs   = "LISTEN_FDS"
+	envSystemdNames = "LISTEN_FDNAMES"
+
+	// 热升级时父进程传给子进程
+	envFDs   = "GOSTAR_LISTEN_FDS"
+	envNames = "GOSTAR_LISTEN_FDNAMES"
+	envReady = "GOSTAR_READY_FD"
+
+	// 继承的 fd 从 3 开始（0/1/2 为标准输入输出）
+	fdStart = 3
+
+	unixPrefix = "unix:"
+)
+
+// Manager 负责创建或继承监听 socket，并记录正在使用的 listener 以便热升级时传给子进程
+type Manager struct {
+	reusePort bool
+
+	mu        sync.Mutex
+	inherited []inheritedListener
+	active    []namedListener
+}
+
+type inheritedListener struct {
+	name string
+	ln   net.Listener
+	used bool
+}
+
+type namedListener struct {
+	name string
+	ln   net.Listener
+}
+
+// NewManager 读取 systemd 或父进程传入的 fd；reusePort 为 true 时新建的 TCP socket 开启 SO_REUSEPORT
+func NewManager(reusePort bool) (*Manager, error) {
+	m := &Manager{reusePort: reusePort}
+	if err := m.inherit(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// inherit 解析环境变量中的 fd，解析后清除这些变量，避免再传给下一代进程
+func (m *Manager) inherit() error {
+	count, names := 0, ""
+	switch {
+	case os.Getenv(envFDs) != "":
+		count, _ = strconv.Atoi(os.Getenv(envFDs))
+		names = os.Getenv(envNames)
+	case os.Getenv(envSystemdFDs) != "" && os.Getenv(envSystemdPID) == strconv.Itoa(os.Getpid()):
+		count, _ = strconv.Atoi(os.Getenv(envSystemdFDs))
+		names = os.Getenv(envSystemdNames)
+	}
+	for _, key := range []string{envFDs, envNames, envSystemdPID, envSystemdFDs, envSystemdNames} {
+		_ = os.Unsetenv(key)
+	}
+
+	nameList := strings.Split(names, ":")
+	for i := 0; i < count; i++ {
+		f := os.NewFile(uintptr(fdStart+i), fmt.Sprintf("listener-%d", i))
+		ln, err := net.FileListener(f)
+		_ = f.Close() // FileListener 内部已 dup
+		if err != nil {
+			return fmt.Errorf("listener: inherit fd %d: %w", fdStart+i, err)
+		}
+		name := ""
+		if i < len(nameList) {
+			name = nameList[i]
+		}
+		m.inherited = append(m.inherited, inheritedListener{name: name, ln: ln})
+	}
+	return nil
+}
+
+// Inherited 是否从 systemd 或父进程继承了 socket
+func (m *Manager) Inherited() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.inherited) > 0
+}
+
+// Listen 返回名为 name 的 listener：优先使用同名或同地址的继承 socket，否则新建。
+// addr 形如 ":8080"、"127.0.0.1:8080" 或 "unix:/run/go-star.sock"。
+func (m *Manager) Listen(name, addr string) (net.Listener, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if ln := m.takeInherited(name, addr); ln != nil {
+		m.active = append(m.active, namedListener{name: name, ln: ln})
+		return ln, nil
+	}
+
+	var (
+		ln  net.Listener
+		err error
+	)
+	if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+		if err := removeStaleSocket(path); err != nil {
+			return nil, err
+		}
+		ln, err = net.Listen("unix", path)
+	} else {
+		lc := net.ListenConfig{}
+		if m.reusePort {
+			lc.Control = reusePortControl
+		}
+		ln, err = lc.Listen(context.Background(), "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.active = append(m.active, namedListener{name: name, ln: ln})
+	return ln, nil
+}
+
+// removeStaleSocket 清理上次异常退出残留的 socket 文件：连接被拒绝说明已无进程监听，
+// 其他情况（仍可连接、无权限等）不删除，返回地址已被占用
+func removeStaleSocket(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("listener: %s: %w", path, syscall.EADDRINUSE)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("listener: %s: %w (%v)", path, syscall.EADDRINUSE, err)
+	}
+	return os.Remove(path)
+}
+
+// takeInherited 先按名字匹配，再按地址匹配
+func (m *Manager) takeInherited(name, addr string) net.Listener {
+	for i := range m.inherited {
+		il := &m.inherited[i]
+		if !il.used && il.name != "" && il.name == name {
+			il.used = true
+			return il.ln
+		}
+	}
+	for i := range m.inherited {
+		il := &m.inherited[i]
+		if !il.used && sameAddr(il.ln.Addr(), addr) {
+			il.used = true
+			return il.ln
+		}
+	}
+	return nil
+}
+
+// sameAddr 比较继承的 socket 地址与配置地址，host 为空时只比较端口
+func sameAddr(a net.Addr, addr string) bool {
+	if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+		return a.Network() == "unix" && a.String() == path
+	}
+	if a.Network() != "tcp" {
+		return false
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	aHost, aPort, err := net.SplitHostPort(a.String())
+	if err != nil || aPort != port {
+		return false
+	}
+	return host == "" || host == aHost
+}
+
+// Close 关闭未被使用的继承 socket
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var errs []error
+	for _, il := range m.inherited {
+		if !il.used {
+			errs = append(errs, il.ln.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyReady 子进程启动完成后通知父进程，父进程随后开始排空退出。
+// 不是由热升级启动时什么也不做。
+func NotifyReady() error {
+	v := os.Getenv(envReady)
+	if v == "" {
+		return nil
+	}
+	_ = os.Unsetenv(envReady)
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("listener: bad %s: %w", envReady, err)
+	}
+	f := os.NewFile(uintptr(fd), "ready")
+	defer f.Close()
+	_, err = f.Write([]byte{1})
+	return err
+}
@@ -0,0 +1,107 @@
+package listener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestManager(t *testing.T) {
+	Convey("监听管理测试", t, func() {
+		Convey("unix socket", func() {
+			m, err := NewManager(false)
+			So(err, ShouldBeNil)
+
+			path := filepath.Join(t.TempDir(), "go-star.sock")
+			ln, err := m.Listen("http", "unix:"+path)
+			So(err, ShouldBeNil)
+			defer ln.Close()
+			So(ln.Addr().Network(), ShouldEqual, "unix")
+
+			conn, err := net.Dial("unix", path)
+			So(err, ShouldBeNil)
+			conn.Close()
+
+			Convey("仍在监听的 socket 不删除", func() {
+				m2, err := NewManager(false)
+				So(err, ShouldBeNil)
+				_, err = m2.Listen("http", "unix:"+path)
+				So(errors.Is(err, syscall.EADDRINUSE), ShouldBeTrue)
+				conn, err := net.Dial("unix", path)
+				So(err, ShouldBeNil)
+				conn.Close()
+			})
+		})
+
+		Convey("清理异常退出残留的 socket 文件", func() {
+			path := filepath.Join(t.TempDir(), "go-star.sock")
+			stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+			So(err, ShouldBeNil)
+			stale.SetUnlinkOnClose(false)
+			stale.Close()
+			_, err = os.Stat(path)
+			So(err, ShouldBeNil)
+
+			m, err := NewManager(false)
+			So(err, ShouldBeNil)
+			ln, err := m.Listen("http", "unix:"+path)
+			So(err, ShouldBeNil)
+			ln.Close()
+		})
+
+		Convey("SO_REUSEPORT 允许重复绑定同一端口", func() {
+			if runtime.GOOS != "linux" {
+				SkipSo("SO_REUSEPORT only on linux")
+				return
+			}
+			m, err := NewManager(true)
+			So(err, ShouldBeNil)
+
+			ln1, err := m.Listen("http", "127.0.0.1:0")
+			So(err, ShouldBeNil)
+			defer ln1.Close()
+
+			ln2, err := m.Listen("http2", ln1.Addr().String())
+			So(err, ShouldBeNil)
+			ln2.Close()
+		})
+
+		Convey("地址匹配", func() {
+			tcp := &net.TCPAddr{IP: net.IPv6zero, Port: 8080}
+			So(sameAddr(tcp, ":8080"), ShouldBeTrue)
+			So(sameAddr(tcp, ":9090"), ShouldBeFalse)
+			So(sameAddr(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8080}, "127.0.0.1:8080"), ShouldBeTrue)
+			So(sameAddr(&net.UnixAddr{Name: "/run/a.sock", Net: "unix"}, "unix:/run/a.sock"), ShouldBeTrue)
+			So(sameAddr(tcp, "unix:/run/a.sock"), ShouldBeFalse)
+		})
+
+		Convey("systemd 下热升级", func() {
+			Convey("没有 NOTIFY_SOCKET 时拒绝升级", func() {
+				t.Setenv(envInvocationID, "test")
+				t.Setenv(envNotifySocket, "")
+				m, err := NewManager(false)
+				So(err, ShouldBeNil)
+				So(m.Upgrade(0), ShouldEqual, ErrSystemdNoNotify)
+			})
+
+			Convey("通过 notify socket 发送 MAINPID", func() {
+				path := filepath.Join(t.TempDir(), "notify.sock")
+				conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+				So(err, ShouldBeNil)
+				defer conn.Close()
+
+				So(sdNotify(path, "MAINPID=42"), ShouldBeNil)
+				buf := make([]byte, 64)
+				n, err := conn.Read(buf)
+				So(err, ShouldBeNil)
+				So(string(buf[:n]), ShouldEqual, "MAINPID=42")
+			})
+		})
+	})
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package listener
+
+import "syscall"
+
+// reusePortControl 非 Linux 平台不支持 SO_REUSEPORT，忽略该选项
+func reusePortControl(network, address string, c syscall.RawConn) error {
+	return nil
+}
@@ -0,0 +1,159 @@
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultUpgradeTimeout 等待子进程就绪的最长时间
+const DefaultUpgradeTimeout = 30 * time.Second
+
+// systemd 为服务进程设置的环境变量
+const (
+	envNotifySocket = "NOTIFY_SOCKET"
+	envInvocationID = "INVOCATION_ID"
+)
+
+// ErrSystemdNoNotify systemd 下没有 NOTIFY_SOCKET，无法把主进程换成子进程，
+// 旧进程退出会让 systemd 停掉整个服务
+var ErrSystemdNoNotify = errors.New("listener: upgrade under systemd requires NotifyAccess=main or all")
+
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Upgrade 以相同参数启动新进程，把正在使用的监听 fd 传给它，并等待其就绪。
+// 返回 nil 后调用方应开始优雅关闭；返回错误时旧进程继续服务。
+// 在 systemd 下运行时，子进程就绪后通过 NOTIFY_SOCKET 发送 MAINPID，
+// 因此 unit 需配置 NotifyAccess=main 或 all，否则拒绝升级。
+func (m *Manager) Upgrade(timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultUpgradeTimeout
+	}
+	notifySocket := os.Getenv(envNotifySocket)
+	if notifySocket == "" && os.Getenv(envInvocationID) != "" {
+		return ErrSystemdNoNotify
+	}
+
+	m.mu.Lock()
+	var (
+		files []*os.File
+		names []string
+	)
+	for _, nl := range m.active {
+		f, ok := nl.ln.(filer)
+		if !ok {
+			m.mu.Unlock()
+			closeAll(files)
+			return fmt.Errorf("listener: %s (%T) cannot be passed to child", nl.name, nl.ln)
+		}
+		// 旧进程关闭 unix socket 时不能删除 socket 文件，新进程还在用
+		if ul, ok := nl.ln.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+		file, err := f.File()
+		if err != nil {
+			m.mu.Unlock()
+			closeAll(files)
+			return fmt.Errorf("listener: dup %s: %w", nl.name, err)
+		}
+		files = append(files, file)
+		names = append(names, nl.name)
+	}
+	m.mu.Unlock()
+	defer closeAll(files)
+
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer ready.Close()
+
+	cmd := exec.Command(os.Args[0], os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = append(files, readyW)
+	cmd.Env = append(childEnv(),
+		envFDs+"="+strconv.Itoa(len(files)),
+		envNames+"="+strings.Join(names, ":"),
+		envReady+"="+strconv.Itoa(fdStart+len(files)),
+	)
+	if err := cmd.Start(); err != nil {
+		readyW.Close()
+		return fmt.Errorf("listener: start child: %w", err)
+	}
+	readyW.Close() // 只有子进程持有写端，子进程退出时读端收到 EOF
+
+	result := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if _, err := ready.Read(buf); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("child exited before becoming ready")
+			}
+			result <- err
+			return
+		}
+		result <- nil
+	}()
+
+	select {
+	case err := <-result:
+		if err != nil {
+			_ = cmd.Process.Kill()
+			return fmt.Errorf("listener: upgrade failed: %w", err)
+		}
+		if notifySocket != "" {
+			if err := sdNotify(notifySocket, fmt.Sprintf("MAINPID=%d", cmd.Process.Pid)); err != nil {
+				_ = cmd.Process.Kill()
+				return fmt.Errorf("listener: notify systemd: %w", err)
+			}
+		}
+		// 子进程由 init 接管，不等待其退出
+		_ = cmd.Process.Release()
+		return nil
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("listener: child not ready within %s", timeout)
+	}
+}
+
+// sdNotify 向 systemd 的 notify socket 发送状态，"@" 开头为抽象命名空间
+func sdNotify(socket, state string) error {
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// childEnv 去掉继承相关变量后的环境
+func childEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		switch key {
+		case envFDs, envNames, envReady, envSystemdPID, envSystemdFDs, envSystemdNames:
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+func closeAll(files []*os.File) {
+	for _, f := range files {
+		_ = f.Close()
+	}
+}
@@ -0,0 +1,249 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FieldModule 子日志器的模块名字段
+const FieldModule = "module"
+
+// Inherit 模块级别跟随全局级别
+const Inherit = "inherit"
+
+// module 模块子日志器：输出、格式和 hook 沿用 L，级别单独维护
+type module struct {
+	l     *logrus.Logger
+	level *logrus.Level // nil 表示跟随全局
+}
+
+// revert 临时调整的级别，到期恢复为 restore
+type revert struct {
+	restore string
+	at      time.Time
+	timer   *time.Timer
+}
+
+var (
+	levelMu sync.Mutex
+	modules = map[string]*module{} // 由 levelMu 保护
+	reverts = map[string]*revert{} // key 为模块名，"" 表示全局；由 levelMu 保护
+)
+
+// Named 返回模块（db、redis、http、service 等）的子日志器，带 module 字段。
+// 未单独设置级别时跟随全局级别，可通过 SetModuleLevel 或配置 log.modules 单独调整。
+func Named(name string) *logrus.Entry {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	return logrus.NewEntry(moduleLocked(name).l).WithField(FieldModule, name)
+}
+
+// NamedFromContext 同 Named，并带上 ctx 中的请求字段
+func NamedFromContext(ctx context.Context, name string) *logrus.Entry {
+	return Named(name).WithFields(Fields(ctx))
+}
+
+// moduleLocked 取出或创建模块，调用方持有 levelMu
+func moduleLocked(name string) *module {
+	m, ok := modules[name]
+	if !ok {
+		m = &module{l: &logrus.Logger{
+			Out:       forward{},
+			Formatter: forward{},
+			Hooks:     make(logrus.LevelHooks),
+			Level:     globalLevel(),
+			ExitFunc:  os.Exit,
+		}}
+		if L != nil {
+			m.l.ReplaceHooks(L.Hooks)
+		}
+		modules[name] = m
+	}
+	return m
+}
+
+// forward 子日志器的输出和格式转给当前的 L，Init 或热更新替换后立即生效
+type forward struct{}
+
+func (forward) Write(p []byte) (int, error) {
+	if L == nil {
+		return os.Stderr.Write(p)
+	}
+	return L.Out.Write(p)
+}
+
+func (forward) Format(e *logrus.Entry) ([]byte, error) {
+	if L == nil {
+		return (&logrus.TextFormatter{}).Format(e)
+	}
+	return L.Formatter.Format(e)
+}
+
+func globalLevel() logrus.Level {
+	if L == nil {
+		return logrus.InfoLevel
+	}
+	return L.GetLevel()
+}
+
+// syncModules Init 重建 L 后让已有子日志器共用新的 hook 和级别
+func syncModules() {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	for _, m := range modules {
+		m.l.ReplaceHooks(L.Hooks)
+		if m.level == nil {
+			m.l.SetLevel(L.GetLevel())
+		}
+	}
+}
+
+// SetModuleLevel 调整级别：module 为空表示全局，level 为 Inherit 表示模块恢复跟随全局。
+// ttl > 0 时到期自动恢复为临时调整之前的级别，期间再次临时调整只顺延到期时间；
+// ttl 为 0 是永久调整，同时取消待恢复的调整。
+func SetModuleLevel(module, level string, ttl time.Duration) error {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	return setLevelLocked(module, level, ttl)
+}
+
+func setLevelLocked(module, level string, ttl time.Duration) error {
+	prev := levelLocked(module)
+	if err := applyLevel(module, level); err != nil {
+		return err
+	}
+
+	r := reverts[module]
+	if ttl <= 0 {
+		if r != nil {
+			r.timer.Stop()
+			delete(reverts, module)
+		}
+		return nil
+	}
+	if r == nil {
+		r = &revert{restore: prev}
+		reverts[module] = r
+	} else {
+		r.timer.Stop()
+	}
+	r.at = time.Now().Add(ttl)
+	r.timer = time.AfterFunc(ttl, func() {
+		levelMu.Lock()
+		defer levelMu.Unlock()
+		if reverts[module] != r {
+			return
+		}
+		delete(reverts, module)
+		if err := applyLevel(module, r.restore); err != nil {
+			Errorf("revert log level of %s: %v", moduleName(module), err)
+			return
+		}
+		Infof("log level of %s reverted to %s", moduleName(module), r.restore)
+	})
+	return nil
+}
+
+// applyLevel 设置级别，调用方持有 levelMu
+func applyLevel(module, level string) error {
+	if module == "" {
+		lvl, err := logrus.ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		if L == nil {
+			return fmt.Errorf("logger not initialized")
+		}
+		L.SetLevel(lvl)
+		for _, m := range modules {
+			if m.level == nil {
+				m.l.SetLevel(lvl)
+			}
+		}
+		return nil
+	}
+
+	if level == Inherit || level == "" {
+		m := moduleLocked(module)
+		m.level = nil
+		m.l.SetLevel(globalLevel())
+		return nil
+	}
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	m := moduleLocked(module)
+	m.level = &lvl
+	m.l.SetLevel(lvl)
+	return nil
+}
+
+// levelLocked 当前设置：全局为级别名，跟随全局的模块为 Inherit
+func levelLocked(module string) string {
+	if module == "" {
+		return globalLevel().String()
+	}
+	if m, ok := modules[module]; ok && m.level != nil {
+		return m.level.String()
+	}
+	return Inherit
+}
+
+func moduleName(module string) string {
+	if module == "" {
+		return "global"
+	}
+	return module
+}
+
+// StepLevel 全局级别调高（delta > 0，更详细）或调低一级，范围 error ~ trace；ttl 同 SetModuleLevel
+func StepLevel(delta int, ttl time.Duration) (string, error) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	lvl := int(globalLevel()) + delta
+	lvl = max(min(lvl, int(logrus.TraceLevel)), int(logrus.ErrorLevel))
+	level := logrus.Level(lvl).String()
+	return level, setLevelLocked("", level, ttl)
+}
+
+// LevelInfo 某个模块的级别，Module 为空表示全局
+type LevelInfo struct {
+	Module   string     `json:"module"`
+	Level    string     `json:"level"`             // 实际生效的级别
+	Inherit  bool       `json:"inherit,omitempty"` // 跟随全局
+	RevertTo string     `json:"revert_to,omitempty"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// Levels 全局和各模块的级别，全局在前，模块按名称排序
+func Levels() []LevelInfo {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	names := []string{""}
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]LevelInfo, 0, len(names))
+	for _, name := range names {
+		info := LevelInfo{Module: name, Level: globalLevel().String()}
+		if m, ok := modules[name]; ok {
+			info.Level = m.l.GetLevel().String()
+			info.Inherit = m.level == nil
+		}
+		if r, ok := reverts[name]; ok {
+			at := r.at
+			info.RevertTo, info.RevertAt = r.restore, &at
+		}
+		list = append(list, info)
+	}
+	return list
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestModuleLevel(t *testing.T) {
+	Convey("模块子日志器的级别", t, func() {
+		var buf bytes.Buffer
+		L = logrus.New()
+		L.SetOutput(&buf)
+		L.SetFormatter(&logrus.JSONFormatter{})
+		syncModules()
+		So(SetLevel("info"), ShouldBeNil)
+		for _, name := range []string{"db", "http"} {
+			So(SetModuleLevel(name, Inherit, 0), ShouldBeNil)
+		}
+
+		last := func() map[string]interface{} {
+			var e map[string]interface{}
+			So(json.Unmarshal(buf.Bytes(), &e), ShouldBeNil)
+			buf.Reset()
+			return e
+		}
+
+		Convey("缺省跟随全局，单独设置后互不影响", func() {
+			Named("db").Debug("hidden")
+			So(buf.Len(), ShouldEqual, 0)
+
+			So(SetModuleLevel("db", "debug", 0), ShouldBeNil)
+			ctx := WithContext(context.Background(), logrus.Fields{FieldRequestID: "req-1"})
+			NamedFromContext(ctx, "db").Debug("sql")
+			e := last()
+			So(e[FieldModule], ShouldEqual, "db")
+			So(e[FieldRequestID], ShouldEqual, "req-1")
+
+			Named("http").Debug("hidden")
+			L.Debug("hidden")
+			So(buf.Len(), ShouldEqual, 0)
+
+			So(SetLevel("warn"), ShouldBeNil)
+			Named("http").Info("hidden")
+			So(buf.Len(), ShouldEqual, 0)
+			Named("db").Debug("sql")
+			So(last()["level"], ShouldEqual, "debug")
+		})
+
+		Convey("ttl 到期恢复为第一次临时调整之前的级别", func() {
+			So(SetModuleLevel("db", "debug", 50*time.Millisecond), ShouldBeNil)
+			So(SetModuleLevel("db", "trace", 50*time.Millisecond), ShouldBeNil)
+
+			levels := Levels()
+			So(levels[0].Module, ShouldEqual, "")
+			for _, info := range levels {
+				if info.Module == "db" {
+					So(info.Level, ShouldEqual, "trace")
+					So(info.RevertTo, ShouldEqual, Inherit)
+					So(info.RevertAt, ShouldNotBeNil)
+				}
+			}
+
+			time.Sleep(150 * time.Millisecond)
+			Named("db").Debug("hidden")
+			So(buf.String(), ShouldNotContainSubstring, "hidden")
+		})
+
+		Convey("永久调整取消待恢复的调整", func() {
+			So(SetLevel("debug"), ShouldBeNil)
+			So(SetModuleLevel("", "trace", 50*time.Millisecond), ShouldBeNil)
+			So(SetLevel("warn"), ShouldBeNil)
+			time.Sleep(100 * time.Millisecond)
+			So(GetLevel(), ShouldEqual, "warning")
+		})
+
+		Convey("信号按级别步进，范围 error ~ trace", func() {
+			level, err := StepLevel(1, 0)
+			So(err, ShouldBeNil)
+			So(level, ShouldEqual, "debug")
+			_, _ = StepLevel(1, 0)
+			level, _ = StepLevel(1, 0)
+			So(level, ShouldEqual, "trace")
+
+			for i := 0; i < 6; i++ {
+				level, _ = StepLevel(-1, 0)
+			}
+			So(level, ShouldEqual, "error")
+		})
+
+		Convey("非法级别", func() {
+			So(SetModuleLevel("db", "verbose", 0), ShouldNotBeNil)
+			So(SetModuleLevel("", Inherit, 0), ShouldNotBeNil)
+		})
+	})
+}
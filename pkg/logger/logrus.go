@@ -2,15 +2,14 @@ package logger
 
 import (
 	"context"
-	"fmt"
 	"io"
-	"os"
-	"path/filepath"
+	"log/slog"
+	"reflect"
 	"time"
 
 	"github.com/jiujuan/go-star/pkg/config"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/natefinch/lumberjack.v2"
+	"go.uber.org/fx"
 )
 
 // 全局单例
@@ -29,45 +28,118 @@ func Init(cfg *config.Config) {
 	}
 	L.SetLevel(level)
 
-	// 2. 格式
-	switch cfg.Log.Format {
-	case "text":
-		L.SetFormatter(&logrus.TextFormatter{
-			TimestampFormat: time.RFC3339,
-			FullTimestamp:   true,
-		})
-	default:
-		L.SetFormatter(&logrus.JSONFormatter{
-			TimestampFormat: time.RFC3339,
-		})
+	// 2. L 自身不输出，由 outputHook 按 console / file / level_files 各自的格式写出
+	L.SetOutput(io.Discard)
+	L.SetFormatter(discard{})
+	sampler.Update(cfg.Log.Sampling)
+
+	// 3. 脱敏 hook 先于输出执行
+	if err := redact.Update(cfg.Log.Redact); err != nil {
+		L.Errorf("log redact: %v", err)
 	}
+	L.AddHook(redact)
 
-	// 3. 输出目标组合
-	var outs []io.Writer
-	if cfg.Log.Console {
-		outs = append(outs, os.Stdout)
-	}
-	if cfg.Log.File.Enable {
-		// 使用 lumberjack 实现自动切割 + 清理
-		// 创建目录
-		_ = os.MkdirAll(filepath.Dir(cfg.Log.File.Path), 0o755)
-		outs = append(outs, &lumberjack.Logger{
-			Filename:   cfg.Log.File.Path,
-			MaxSize:    parseSize(cfg.Log.File.MaxSize), // 100MB
-			MaxAge:     parseDurationDays(cfg.Log.File.MaxAge),
-			MaxBackups: cfg.Log.File.MaxBackups,
-			Compress:   cfg.Log.File.Compress,
-		})
+	// 4. 输出，重复 Init 时关闭上一次打开的文件
+	if outputs != nil {
+		_ = outputs.Close()
+	}
+	outputs = newOutputHook(cfg.Log)
+	L.AddHook(outputs)
+
+	// 5. slog 与标准库 log 同样写入 L，统一格式和输出
+	slog.SetDefault(slog.New(NewSlogHandler()))
+
+	// 6. 模块子日志器改用新的 L，并按 log.modules 设置级别
+	syncModules()
+	for name, lvl := range cfg.Log.Modules {
+		if err := SetModuleLevel(name, lvl, 0); err != nil {
+			L.Errorf("log level %q of %s: %v", lvl, name, err)
+		}
 	}
 
-	switch len(outs) {
-	case 0:
-		L.SetOutput(io.Discard)
-	case 1:
-		L.SetOutput(outs[0])
-	default:
-		L.SetOutput(io.MultiWriter(outs...))
+	// 7. 配置热更新时调整级别、格式和脱敏规则
+	if unsubscribe != nil {
+		unsubscribe()
 	}
+	unsubscribe = config.LogSection.Subscribe(onConfigChange)
+}
+
+var (
+	// unsubscribe 取消上一次 Init 的配置订阅
+	unsubscribe func()
+	// outputs 当前的输出 hook
+	outputs *outputHook
+)
+
+// onConfigChange 响应 log 配置变化
+func onConfigChange(old, new config.LogConfig) {
+	if new.Level != old.Level {
+		if new.Level == "" {
+			new.Level = logrus.InfoLevel.String()
+		}
+		if err := SetLevel(new.Level); err != nil {
+			L.Errorf("log level %q from config: %v", new.Level, err)
+		} else {
+			L.Infof("log level changed to %s by config reload", L.GetLevel())
+		}
+	}
+	for name, lvl := range new.Modules {
+		if lvl == old.Modules[name] {
+			continue
+		}
+		if err := SetModuleLevel(name, lvl, 0); err != nil {
+			L.Errorf("log level %q of %s from config: %v", lvl, name, err)
+		}
+	}
+	for name := range old.Modules {
+		if _, ok := new.Modules[name]; !ok {
+			_ = SetModuleLevel(name, Inherit, 0)
+		}
+	}
+	if new.Format != old.Format || new.Console.Format != old.Console.Format || new.File.Format != old.File.Format {
+		outputs.setFormats(new)
+	}
+	if outputsChanged(old, new) {
+		L.Warnf("log.console / log.file changed, restart to apply")
+	}
+	if !reflect.DeepEqual(new.Redact, old.Redact) {
+		if err := redact.Update(new.Redact); err != nil {
+			L.Errorf("log redact from config: %v", err)
+		}
+	}
+	if !reflect.DeepEqual(new.Sampling, old.Sampling) {
+		sampler.Update(new.Sampling)
+	}
+}
+
+// outputsChanged 输出目标或切割规则是否变化，格式除外
+func outputsChanged(old, new config.LogConfig) bool {
+	old.Console.Format, new.Console.Format = "", ""
+	old.File.Format, new.File.Format = "", ""
+	return !reflect.DeepEqual(old.Console, new.Console) || !reflect.DeepEqual(old.File, new.File)
+}
+
+// newFormatter text 或 json（缺省）
+func newFormatter(format string) logrus.Formatter {
+	if format == "text" {
+		return &logrus.TextFormatter{
+			TimestampFormat: time.RFC3339,
+			FullTimestamp:   true,
+		}
+	}
+	return &logrus.JSONFormatter{
+		TimestampFormat: time.RFC3339,
+	}
+}
+
+// SetLevel 运行时调整全局日志级别，跟随全局的模块一并生效；会取消待恢复的临时调整
+func SetLevel(level string) error {
+	return SetModuleLevel("", level, 0)
+}
+
+// GetLevel 当前日志级别
+func GetLevel() string {
+	return L.GetLevel().String()
 }
 
 // ---------------- 快捷函数 ----------------
@@ -99,47 +171,60 @@ func WithFields(fields logrus.Fields) *logrus.Entry {
 }
 
 // ---------------- Context 链路字段注入 ----------------
-// FromContext 取出保存在 ctx 中的 fields
+// 请求级字段，由中间件写入 c.Request.Context()
+const (
+	FieldRequestID = "request_id"
+	FieldTraceID   = "trace_id"
+	FieldUserID    = "user_id"
+	FieldTenant    = "tenant"
+)
+
+// FromContext 取出保存在 ctx 中的 fields。
+// 字段随 ctx 传递，请求中启动的 goroutine 只要传入 ctx（或 context.WithoutCancel(ctx)）即可带上。
 func FromContext(ctx context.Context) *logrus.Entry {
-	if fields, ok := ctx.Value(ctxKey{}).(logrus.Fields); ok {
-		return L.WithFields(fields)
+	if ctx != nil {
+		if fields, ok := ctx.Value(ctxKey{}).(logrus.Fields); ok {
+			return L.WithFields(fields)
+		}
 	}
 	return logrus.NewEntry(L)
 }
 
-// WithContext 把 fields 写入 ctx（中间件或 handler 使用）
+// WithContext 把 fields 合并进 ctx 中已有的字段（中间件或 handler 使用），同名字段覆盖；
+// 返回新的 ctx，原 ctx 不受影响
 func WithContext(ctx context.Context, fields logrus.Fields) context.Context {
-	return context.WithValue(ctx, ctxKey{}, fields)
-}
-
-// ---------------- 内部工具 ----------------
-// parseSize "100MB" -> 100
-func parseSize(s string) int {
-	var v int
-	var unit string
-	_, _ = fmt.Sscanf(s, "%d%s", &v, &unit)
-	switch unit {
-	case "KB", "kb":
-		return v
-	case "MB", "mb":
-		return v
-	case "GB", "gb":
-		return v * 1024
-	default:
-		return v // 默认 MB
+	merged := Fields(ctx)
+	for k, v := range fields {
+		merged[k] = v
 	}
+	return context.WithValue(ctx, ctxKey{}, merged)
 }
 
-// parseDurationDays "30d" -> 30
-func parseDurationDays(s string) int {
-	var v int
-	var unit string
-	_, _ = fmt.Sscanf(s, "%d%s", &v, &unit)
-	if unit == "d" || unit == "D" {
-		return v
+// Fields 返回 ctx 中字段的副本
+func Fields(ctx context.Context) logrus.Fields {
+	out := logrus.Fields{}
+	if ctx != nil {
+		if fields, ok := ctx.Value(ctxKey{}).(logrus.Fields); ok {
+			for k, v := range fields {
+				out[k] = v
+			}
+		}
 	}
-	return 30
+	return out
 }
 
-// Fx 模块
-var Module = fx.Invoke(func(cfg *config.Config) { Init(cfg) })
+// Fx 模块：初始化后监听 SIGUSR1/SIGUSR2 调整级别，退出前输出采样汇总并关闭日志文件
+var Module = fx.Options(
+	fx.Invoke(func(cfg *config.Config) { Init(cfg) }),
+	fx.Invoke(func(lc fx.Lifecycle) {
+		var stop func()
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error { stop = watchSignals(); return nil },
+			OnStop: func(context.Context) error {
+				stop()
+				sampler.Flush()
+				return outputs.Close()
+			},
+		})
+	}),
+)
@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/jiujuan/go-star/pkg/config"
+)
+
+// output 一个输出目标：标准输出、主日志文件或按级别的文件，格式各自独立
+type output struct {
+	w        io.Writer
+	console  bool
+	format   string       // 自身的格式，为空时使用 log.format
+	minLevel logrus.Level // 只写该级别及更严重的
+	f        logrus.Formatter
+}
+
+// outputHook 按 log.console、log.file 把 entry 写到各个输出，L 自身的输出丢弃。
+// 脱敏 hook 先于它执行；采样在这里统一判断，所有输出的取舍一致。
+type outputHook struct {
+	mu      sync.RWMutex // 保护各输出的 formatter，格式热更新时替换
+	outputs []*output
+}
+
+// newOutputHook 按配置打开各输出；文件在第一次写入时创建
+func newOutputHook(cfg config.LogConfig) *outputHook {
+	h := &outputHook{}
+	if cfg.Console.Enable {
+		h.outputs = append(h.outputs, &output{w: os.Stdout, console: true, format: cfg.Console.Format, minLevel: logrus.TraceLevel})
+	}
+	if fc := cfg.File; fc.Enable {
+		h.outputs = append(h.outputs, &output{w: newFileWriter(fc, fc.Path), format: fc.Format, minLevel: logrus.TraceLevel})
+		for _, lf := range fc.LevelFiles {
+			lvl, err := logrus.ParseLevel(lf.Level)
+			if err != nil {
+				continue
+			}
+			h.outputs = append(h.outputs, &output{w: newFileWriter(fc, lf.Path), format: fc.Format, minLevel: lvl})
+		}
+	}
+	h.setFormat(cfg.Format)
+	return h
+}
+
+// newFileWriter daily / hourly 按时间切割，size 交给 lumberjack
+func newFileWriter(fc config.FileConfig, path string) io.Writer {
+	if fc.Rotate == "size" {
+		return &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    fc.MaxSize,
+			MaxAge:     int((fc.MaxAge + 24*time.Hour - 1) / (24 * time.Hour)), // 按天向上取整
+			MaxBackups: fc.MaxBackups,
+			Compress:   fc.Compress,
+		}
+	}
+	return newRotateWriter(path, fc.Rotate == "hourly", fc.MaxAge, fc.MaxBackups, fc.Compress)
+}
+
+// setFormat 重建各输出的 formatter，自身未设置格式的输出使用 def
+func (h *outputHook) setFormat(def string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, o := range h.outputs {
+		format := o.format
+		if format == "" {
+			format = def
+		}
+		o.f = newFormatter(format)
+	}
+}
+
+// setFormats 格式热更新：console.format、file.format 或 log.format 变化时调用
+func (h *outputHook) setFormats(cfg config.LogConfig) {
+	h.mu.Lock()
+	for _, o := range h.outputs {
+		if o.console {
+			o.format = cfg.Console.Format
+		} else {
+			o.format = cfg.File.Format
+		}
+	}
+	h.mu.Unlock()
+	h.setFormat(cfg.Format)
+}
+
+// Levels 对所有级别生效，各输出自行按 minLevel 过滤
+func (h *outputHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 写出到各输出；某个输出失败不影响其余输出，错误由 logrus 打印到标准错误
+func (h *outputHook) Fire(e *logrus.Entry) error {
+	if !sampler.Allow(e) {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var first error
+	for _, o := range h.outputs {
+		if e.Level > o.minLevel {
+			continue
+		}
+		b, err := o.f.Format(e)
+		if err == nil {
+			_, err = o.w.Write(b)
+		}
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Close 关闭各文件输出
+func (h *outputHook) Close() error {
+	var first error
+	for _, o := range h.outputs {
+		if c, ok := o.w.(io.Closer); ok && !o.console {
+			if err := c.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// discard L 自身的 formatter：输出由 outputHook 完成，这里不再格式化
+type discard struct{}
+
+func (discard) Format(*logrus.Entry) ([]byte, error) { return nil, nil }
@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/jiujuan/go-star/pkg/config"
+)
+
+func TestOutputs(t *testing.T) {
+	Convey("按级别分文件输出", t, func() {
+		dir := t.TempDir()
+		cfg := &config.Config{Log: config.LogSection.Default()}
+		cfg.Log.Console.Enable = false
+		cfg.Log.File = config.FileConfig{
+			Enable:     true,
+			Path:       filepath.Join(dir, "app.log"),
+			Format:     "text",
+			Rotate:     "daily",
+			LevelFiles: []config.LevelFile{{Level: "warn", Path: filepath.Join(dir, "warn.log")}},
+		}
+		Init(cfg)
+		Reset(func() { _ = outputs.Close() })
+
+		Infof("started")
+		Named("db").Warnf("slow sql")
+		Errorf("failed")
+		So(outputs.Close(), ShouldBeNil)
+
+		app, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+		warn, _ := os.ReadFile(filepath.Join(dir, "warn.log"))
+		So(strings.Count(string(app), "\n"), ShouldEqual, 3)
+		So(string(app), ShouldContainSubstring, `level=info msg=started`)
+		So(string(warn), ShouldNotContainSubstring, "started")
+		So(string(warn), ShouldContainSubstring, "module=db")
+		So(string(warn), ShouldContainSubstring, "msg=failed")
+
+		Convey("格式热更新", func() {
+			next := cfg.Log
+			next.File.Format = "json"
+			onConfigChange(cfg.Log, next)
+			Infof("reloaded")
+			So(outputs.Close(), ShouldBeNil)
+			app, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+			So(string(app), ShouldContainSubstring, `"msg":"reloaded"`)
+		})
+	})
+}
+
+func TestRotateWriter(t *testing.T) {
+	Convey("按小时切割并清理旧文件", t, func() {
+		dir := t.TempDir()
+		now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+		w := newRotateWriter(filepath.Join(dir, "app.log"), true, 0, 2, false)
+		w.now = func() time.Time { return now }
+		Reset(func() { _ = w.Close() })
+
+		// 旧文件按修改时间排序，写入后改为模拟的时间
+		write := func(s string) {
+			_, err := w.Write([]byte(s + "\n"))
+			So(err, ShouldBeNil)
+			So(os.Chtimes(filepath.Join(dir, "app.log"), now, now), ShouldBeNil)
+		}
+		names := func() []string {
+			w.cleanMu.Lock()
+			defer w.cleanMu.Unlock()
+			entries, _ := os.ReadDir(dir)
+			var out []string
+			for _, e := range entries {
+				out = append(out, e.Name())
+			}
+			sort.Strings(out)
+			return out
+		}
+
+		write("a")
+		now = now.Add(10 * time.Minute)
+		write("b")
+		So(names(), ShouldResemble, []string{"app.log"})
+
+		now = now.Add(time.Hour)
+		write("c")
+		backup, _ := os.ReadFile(filepath.Join(dir, "app-2024-05-01T10.log"))
+		So(string(backup), ShouldEqual, "a\nb\n")
+		current, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+		So(string(current), ShouldEqual, "c\n")
+
+		for i := 0; i < 3; i++ {
+			now = now.Add(time.Hour)
+			write("d")
+		}
+		time.Sleep(50 * time.Millisecond)
+		So(names(), ShouldResemble, []string{"app-2024-05-01T12.log", "app-2024-05-01T13.log", "app.log"})
+	})
+
+	Convey("同目录下的按级别文件不当作备份", t, func() {
+		dir := t.TempDir()
+		now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+		app := newRotateWriter(filepath.Join(dir, "app.log"), false, 0, 0, true)
+		errs := newRotateWriter(filepath.Join(dir, "app-error.log"), false, 0, 0, true)
+		app.now = func() time.Time { return now }
+		start := now
+		errs.now = func() time.Time { return start } // 只让 app.log 切割
+		Reset(func() { _ = app.Close(); _ = errs.Close() })
+
+		So(app.isBackup("app-2024-05-01.log"), ShouldBeTrue)
+		So(app.isBackup("app-2024-05-01.2.log.gz"), ShouldBeTrue)
+		So(app.isBackup("app-error.log"), ShouldBeFalse)
+		So(app.isBackup("app-error-2024-05-01.log"), ShouldBeFalse)
+		So(app.isBackup("app-2024-05-01T10.log"), ShouldBeFalse)
+
+		for _, w := range []*rotateWriter{app, errs} {
+			_, err := w.Write([]byte("a\n"))
+			So(err, ShouldBeNil)
+		}
+		now = now.Add(24 * time.Hour)
+		_, err := app.Write([]byte("b\n"))
+		So(err, ShouldBeNil)
+		app.clean(now) // 等同于切割后的后台清理，同步执行
+
+		_, err = errs.Write([]byte("c\n"))
+		So(err, ShouldBeNil)
+		So(errs.Close(), ShouldBeNil)
+		content, err := os.ReadFile(filepath.Join(dir, "app-error.log"))
+		So(err, ShouldBeNil)
+		So(string(content), ShouldEqual, "a\nc\n")
+		So(exists(filepath.Join(dir, "app-error.log.gz")), ShouldBeFalse)
+		So(exists(filepath.Join(dir, "app-2024-05-01.log.gz")), ShouldBeTrue)
+	})
+
+	Convey("旧文件压缩，重启后跨周期的文件先切割", t, func() {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		So(os.WriteFile(path, []byte("old\n"), 0o644), ShouldBeNil)
+		yesterday := time.Now().Add(-24 * time.Hour)
+		So(os.Chtimes(path, yesterday, yesterday), ShouldBeNil)
+
+		w := newRotateWriter(path, false, 0, 0, true)
+		Reset(func() { _ = w.Close() })
+		_, err := w.Write([]byte("new\n"))
+		So(err, ShouldBeNil)
+
+		gz := filepath.Join(dir, "app-"+yesterday.Format("2006-01-02")+".log.gz")
+		So(func() bool {
+			for i := 0; i < 50; i++ {
+				w.cleanMu.Lock()
+				ok := exists(gz)
+				w.cleanMu.Unlock()
+				if ok {
+					return true
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			return false
+		}(), ShouldBeTrue)
+		current, _ := os.ReadFile(path)
+		So(string(current), ShouldEqual, "new\n")
+	})
+}
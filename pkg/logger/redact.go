@@ -0,0 +1,226 @@
+package logger
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jiujuan/go-star/pkg/config"
+)
+
+// partialMask 部分脱敏时替换中间部分的占位
+const partialMask = "****"
+
+// RedactHook 按 log.redact 对日志脱敏：字段名命中的值整体处理，消息和字符串字段中匹配正则的片段单独处理。
+// Init 把它加到 L 上，模块子日志器共用；配置热更新后立即生效。
+type RedactHook struct {
+	r atomic.Pointer[redactor] // nil 表示不脱敏
+}
+
+// redactor 编译后的规则
+type redactor struct {
+	fields   map[string]keep // 小写字段名
+	patterns []pattern
+}
+
+type keep struct{ prefix, suffix int }
+
+type pattern struct {
+	re *regexp.Regexp
+	keep
+}
+
+// redact Init 加到 L 上的脱敏 hook
+var redact = &RedactHook{}
+
+// NewRedactHook 按配置创建 hook，正则有误时返回错误，其余规则照常生效
+func NewRedactHook(cfg config.RedactConfig) (*RedactHook, error) {
+	h := &RedactHook{}
+	return h, h.Update(cfg)
+}
+
+// Update 替换规则；无法编译的正则跳过并在错误中列出
+func (h *RedactHook) Update(cfg config.RedactConfig) error {
+	if !cfg.Enable {
+		h.r.Store(nil)
+		return nil
+	}
+	r := &redactor{fields: make(map[string]keep, len(cfg.Fields))}
+	for _, f := range cfg.Fields {
+		r.fields[strings.ToLower(f.Name)] = keep{f.KeepPrefix, f.KeepSuffix}
+	}
+	var errs []error
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("redact pattern %s: %w", p.Name, err))
+			continue
+		}
+		r.patterns = append(r.patterns, pattern{re, keep{p.KeepPrefix, p.KeepSuffix}})
+	}
+	h.r.Store(r)
+	return errors.Join(errs...)
+}
+
+// Levels 对所有级别生效
+func (h *RedactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 改写 entry 的消息和字段；嵌套的 map、切片、结构体复制后再改，不影响调用方的数据
+func (h *RedactHook) Fire(e *logrus.Entry) error {
+	r := h.r.Load()
+	if r == nil {
+		return nil
+	}
+	e.Message = r.text(e.Message)
+	for k, v := range e.Data {
+		e.Data[k] = r.value(k, v)
+	}
+	return nil
+}
+
+// value 处理 key 对应的值
+func (r *redactor) value(key string, v interface{}) interface{} {
+	if k, ok := r.fields[strings.ToLower(key)]; ok && v != nil {
+		return k.mask(fmt.Sprint(v))
+	}
+	switch val := v.(type) {
+	case string:
+		return r.text(val)
+	case error:
+		// 没有命中时保留原 error，便于 formatter 按 error 输出
+		if s := val.Error(); r.text(s) != s {
+			return r.text(s)
+		}
+		return v
+	case logrus.Fields:
+		return r.mapValue(val)
+	case map[string]interface{}:
+		return r.mapValue(val)
+	case map[string]string:
+		out := make(map[string]string, len(val))
+		for mk, mv := range val {
+			out[mk] = fmt.Sprint(r.value(mk, mv))
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = r.value("", item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i, item := range val {
+			out[i] = r.text(item)
+		}
+		return out
+	case nil, fmt.Stringer, json.Marshaler, encoding.TextMarshaler:
+		// 自带输出格式的类型（time.Time 等）按原样输出
+		return v
+	}
+	return r.reflectValue(v)
+}
+
+// reflectValue 处理结构体（及其指针）和其他类型的切片：结构体按 json tag（没有时为字段名）
+// 展开为 map 后逐个字段处理，如日志中带上的请求体；没有需要脱敏的内容时返回原值
+func (r *redactor) reflectValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return v
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		out := make(map[string]interface{}, rv.NumField())
+		if !r.structFields(rv, out) {
+			return v
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		out := make([]interface{}, rv.Len())
+		changed := false
+		for i := range out {
+			item := rv.Index(i).Interface()
+			out[i] = r.value("", item)
+			changed = changed || !reflect.DeepEqual(out[i], item)
+		}
+		if !changed {
+			return v
+		}
+		return out
+	}
+	return v
+}
+
+// structFields 把 rv 的导出字段写入 out，匿名嵌入的结构体与 encoding/json 一样展开到同一层；
+// 返回是否有字段被改写
+func (r *redactor) structFields(rv reflect.Value, out map[string]interface{}) bool {
+	changed := false
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if f.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			changed = r.structFields(fv, out) || changed
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		// 规则按 json 名或字段名匹配
+		key := name
+		if _, ok := r.fields[strings.ToLower(name)]; !ok {
+			key = f.Name
+		}
+		item := fv.Interface()
+		out[name] = r.value(key, item)
+		changed = changed || !reflect.DeepEqual(out[name], item)
+	}
+	return changed
+}
+
+func (r *redactor) mapValue(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = r.value(k, v)
+	}
+	return out
+}
+
+// text 替换字符串中匹配正则的片段
+func (r *redactor) text(s string) string {
+	for _, p := range r.patterns {
+		s = p.re.ReplaceAllStringFunc(s, p.mask)
+	}
+	return s
+}
+
+// mask 保留首尾若干字符，中间替换为 ****；不保留或值太短时整体替换为 config.Mask
+func (k keep) mask(s string) string {
+	runes := []rune(s)
+	if k.prefix+k.suffix == 0 || k.prefix+k.suffix >= len(runes) {
+		return config.Mask
+	}
+	return string(runes[:k.prefix]) + partialMask + string(runes[len(runes)-k.suffix:])
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/jiujuan/go-star/pkg/config"
+)
+
+func TestRedactHook(t *testing.T) {
+	Convey("日志脱敏", t, func() {
+		var buf bytes.Buffer
+		L = logrus.New()
+		L.SetOutput(&buf)
+		L.SetFormatter(&logrus.JSONFormatter{})
+		h, err := NewRedactHook(config.LogSection.Default().Redact)
+		So(err, ShouldBeNil)
+		L.AddHook(h)
+
+		last := func() map[string]interface{} {
+			var e map[string]interface{}
+			So(json.Unmarshal(buf.Bytes(), &e), ShouldBeNil)
+			buf.Reset()
+			return e
+		}
+
+		Convey("按字段名整体或部分脱敏", func() {
+			body := map[string]interface{}{"Password": "p@ss", "name": "alice"}
+			L.WithFields(logrus.Fields{
+				"Authorization": "Bearer abc.def",
+				"mobile":        "13812345678",
+				"idcard":        "110101199001011234",
+				"body":          body,
+			}).Info("login")
+
+			e := last()
+			So(e["Authorization"], ShouldEqual, config.Mask)
+			So(e["mobile"], ShouldEqual, "138****5678")
+			So(e["idcard"], ShouldEqual, "110101****1234")
+			nested := e["body"].(map[string]interface{})
+			So(nested["Password"], ShouldEqual, config.Mask)
+			So(nested["name"], ShouldEqual, "alice")
+			So(body["Password"], ShouldEqual, "p@ss") // 调用方的数据不受影响
+		})
+
+		Convey("按正则脱敏消息、字符串字段和 error", func() {
+			jwt := "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig_-1"
+			L.WithError(errors.New("call 13912345678 failed")).
+				WithField("header", "Bearer "+jwt).
+				Errorf("panic: bad token %s for 110101199001011234", jwt)
+
+			e := last()
+			So(e["msg"], ShouldEqual, "panic: bad token "+config.Mask+" for 110101****1234")
+			So(e["header"], ShouldEqual, "Bearer "+partialMask)
+			So(e["error"], ShouldEqual, "call 139****5678 failed")
+		})
+
+		Convey("结构体按 json 名或字段名脱敏", func() {
+			type Profile struct {
+				Mobile string
+			}
+			type LoginRequest struct {
+				Username string `json:"username"`
+				Password string `json:"password"`
+				Phone    string `json:"phone"`
+				Secret   string `json:"-"`
+				Profile
+				Created time.Time `json:"created"`
+			}
+			created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+			req := &LoginRequest{Username: "alice", Password: "p@ss", Phone: "13812345678", Secret: "x", Created: created}
+			req.Mobile = "13912345678"
+			L.WithField("req", req).WithField("users", []LoginRequest{*req}).Info("login")
+
+			e := last()
+			m := e["req"].(map[string]interface{})
+			So(m["username"], ShouldEqual, "alice")
+			So(m["password"], ShouldEqual, config.Mask)
+			So(m["phone"], ShouldEqual, "138****5678") // 没有命中字段名时仍按正则处理
+			So(m["Mobile"], ShouldEqual, "139****5678")
+			So(m, ShouldNotContainKey, "Secret")
+			So(m["created"], ShouldEqual, created.Format(time.RFC3339))
+			So(e["users"].([]interface{})[0].(map[string]interface{})["password"], ShouldEqual, config.Mask)
+			So(req.Password, ShouldEqual, "p@ss") // 调用方的数据不受影响
+
+			type Page struct {
+				Page int `json:"page"`
+			}
+			page := Page{Page: 2}
+			So(h.r.Load().value("page", page), ShouldResemble, page)
+		})
+
+		Convey("关闭后原样输出，非法正则返回错误", func() {
+			So(h.Update(config.RedactConfig{}), ShouldBeNil)
+			L.WithField("password", "p@ss").Info("13812345678")
+			e := last()
+			So(e["password"], ShouldEqual, "p@ss")
+			So(e["msg"], ShouldEqual, "13812345678")
+
+			err := h.Update(config.RedactConfig{Enable: true, Patterns: []config.RedactPattern{
+				{Name: "bad", Regex: "("}, {Name: "digits", Regex: `\d+`},
+			}})
+			So(err, ShouldNotBeNil)
+			L.Info("order 42")
+			So(last()["msg"], ShouldEqual, "order "+config.Mask)
+		})
+	})
+}
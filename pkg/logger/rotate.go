@@ -0,0 +1,233 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotateWriter 按天或小时切割的日志文件：跨周期后把当前文件改名为 app-2006-01-02.log（按小时为
+// app-2006-01-02T15.log）再新建，旧文件按 maxAge、maxBackups 清理，可选 gzip 压缩。
+type rotateWriter struct {
+	path       string
+	hourly     bool
+	maxAge     time.Duration
+	maxBackups int
+	compress   bool
+	now        func() time.Time // 测试中替换
+
+	mu    sync.Mutex
+	file  *os.File
+	start time.Time // 当前文件所属周期的起点
+
+	cleanMu sync.Mutex // 串行执行清理
+}
+
+func newRotateWriter(path string, hourly bool, maxAge time.Duration, maxBackups int, compress bool) *rotateWriter {
+	return &rotateWriter{
+		path:       path,
+		hourly:     hourly,
+		maxAge:     maxAge,
+		maxBackups: maxBackups,
+		compress:   compress,
+		now:        time.Now,
+	}
+}
+
+func (w *rotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if !w.period(w.now()).Equal(w.start) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	return w.file.Write(p)
+}
+
+// Close 关闭当前文件，之后再写会重新打开
+func (w *rotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// period t 所在周期的起点
+func (w *rotateWriter) period(t time.Time) time.Time {
+	if w.hourly {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// open 打开或续写当前文件；已有内容时按修改时间确定所属周期，重启后跨周期的文件在下一次写入时切割
+func (w *rotateWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w.file, w.start = f, w.period(w.now())
+	if fi, err := f.Stat(); err == nil && fi.Size() > 0 {
+		w.start = w.period(fi.ModTime().In(w.now().Location()))
+	}
+	return nil
+}
+
+// rotate 当前文件改名为所属周期的备份，新建文件，后台清理旧文件
+func (w *rotateWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	if err := os.Rename(w.path, w.backupName(w.start)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	go w.clean(w.now())
+	return nil
+}
+
+// layout 备份文件名中的时间格式
+func (w *rotateWriter) layout() string {
+	if w.hourly {
+		return "2006-01-02T15"
+	}
+	return "2006-01-02"
+}
+
+// backupName app.log -> app-2006-01-02.log，同名已存在时追加序号 app-2006-01-02.1.log
+func (w *rotateWriter) backupName(start time.Time) string {
+	ext := filepath.Ext(w.path)
+	base := strings.TrimSuffix(w.path, ext) + "-" + start.Format(w.layout())
+	name := base + ext
+	for i := 1; exists(name) || exists(name+".gz"); i++ {
+		name = fmt.Sprintf("%s.%d%s", base, i, ext)
+	}
+	return name
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// backups 已切割的旧文件，新的在前
+func (w *rotateWriter) backups() ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(filepath.Dir(w.path))
+	if err != nil {
+		return nil, err
+	}
+	var list []os.FileInfo
+	for _, e := range entries {
+		if e.IsDir() || !w.isBackup(e.Name()) {
+			continue
+		}
+		if fi, err := e.Info(); err == nil {
+			list = append(list, fi)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ModTime().After(list[j].ModTime()) })
+	return list, nil
+}
+
+// isBackup name 是否为 backupName 生成的文件：前缀之后必须是切割时间（可带 .N 序号），
+// 避免把同目录下 app-error.log 这类其他日志当作备份
+func (w *rotateWriter) isBackup(name string) bool {
+	ext := filepath.Ext(w.path)
+	rest, ok := strings.CutPrefix(name, strings.TrimSuffix(filepath.Base(w.path), ext)+"-")
+	if !ok {
+		return false
+	}
+	rest = strings.TrimSuffix(rest, ".gz")
+	if rest, ok = strings.CutSuffix(rest, ext); !ok {
+		return false
+	}
+	if i := strings.LastIndexByte(rest, '.'); i >= 0 {
+		if _, err := strconv.Atoi(rest[i+1:]); err != nil {
+			return false
+		}
+		rest = rest[:i]
+	}
+	_, err := time.Parse(w.layout(), rest)
+	return err == nil
+}
+
+// clean 删除超出保留个数或时长的旧文件，压缩其余未压缩的旧文件
+func (w *rotateWriter) clean(now time.Time) {
+	w.cleanMu.Lock()
+	defer w.cleanMu.Unlock()
+	list, err := w.backups()
+	if err != nil {
+		return
+	}
+	dir := filepath.Dir(w.path)
+	cutoff := now.Add(-w.maxAge)
+	for i, fi := range list {
+		path := filepath.Join(dir, fi.Name())
+		if (w.maxBackups > 0 && i >= w.maxBackups) || (w.maxAge > 0 && fi.ModTime().Before(cutoff)) {
+			_ = os.Remove(path)
+			continue
+		}
+		if w.compress && !strings.HasSuffix(path, ".gz") {
+			_ = gzipFile(path)
+		}
+	}
+}
+
+// gzipFile path 压缩为 path.gz 后删除原文件，保留修改时间供按时长清理
+func gzipFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path + ".gz")
+		}
+	}()
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	_ = os.Chtimes(path+".gz", fi.ModTime(), fi.ModTime())
+	return os.Remove(path)
+}
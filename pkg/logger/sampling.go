@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jiujuan/go-star/pkg/config"
+)
+
+// FieldSuppressed 汇总日志中被丢弃的条数
+const FieldSuppressed = "suppressed"
+
+// Sampler 按 log.sampling 对相同日志限流：统计窗口内同一级别、模块和消息的前 First 条照常输出，
+// 之后每 Thereafter 条输出 1 条；每个窗口结束时为有丢弃的消息输出一条汇总。
+type Sampler struct {
+	mu       sync.Mutex
+	rules    map[logrus.Level]config.SamplingRule // nil 表示不采样
+	interval time.Duration
+	counts   map[sampleKey]*sampleCount
+	stop     chan struct{} // 关闭时停止当前的汇总 goroutine
+}
+
+type sampleKey struct {
+	level   logrus.Level
+	module  string
+	message string
+}
+
+type sampleCount struct {
+	n          int
+	suppressed int
+}
+
+// sampler 输出前统一判断的采样器，见 outputHook
+var sampler = &Sampler{}
+
+// bypassKey 汇总日志不再参与采样
+type bypassKey struct{}
+
+// Update 替换采样规则；统计窗口变化时先输出当前窗口的汇总
+func (s *Sampler) Update(cfg config.SamplingConfig) {
+	rules := make(map[logrus.Level]config.SamplingRule, len(cfg.Levels))
+	for name, rule := range cfg.Levels {
+		if lvl, err := logrus.ParseLevel(name); err == nil {
+			rules[lvl] = rule
+		}
+	}
+	if !cfg.Enable || cfg.Interval <= 0 || len(rules) == 0 {
+		rules = nil
+	}
+
+	s.mu.Lock()
+	restart := (rules == nil) != (s.rules == nil) || cfg.Interval != s.interval
+	s.rules, s.interval = rules, cfg.Interval
+	if restart && s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	if restart && rules != nil {
+		s.stop = make(chan struct{})
+		go s.loop(cfg.Interval, s.stop)
+	}
+	s.mu.Unlock()
+
+	if restart {
+		s.Flush()
+	}
+}
+
+func (s *Sampler) loop(interval time.Duration, stop chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			s.Flush()
+		}
+	}
+}
+
+// Allow 判断 entry 是否输出
+func (s *Sampler) Allow(e *logrus.Entry) bool {
+	if e.Context != nil && e.Context.Value(bypassKey{}) != nil {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rule, ok := s.rules[e.Level]
+	if !ok {
+		return true
+	}
+	module, _ := e.Data[FieldModule].(string)
+	key := sampleKey{e.Level, module, e.Message}
+	c := s.counts[key]
+	if c == nil {
+		if s.counts == nil {
+			s.counts = make(map[sampleKey]*sampleCount)
+		}
+		c = &sampleCount{}
+		s.counts[key] = c
+	}
+	c.n++
+	if c.n <= rule.First || (rule.Thereafter > 0 && (c.n-rule.First)%rule.Thereafter == 0) {
+		return true
+	}
+	c.suppressed++
+	return false
+}
+
+// Flush 结束当前统计窗口，为有丢弃的消息各输出一条汇总
+func (s *Sampler) Flush() {
+	s.mu.Lock()
+	counts := s.counts
+	s.counts = nil
+	s.mu.Unlock()
+
+	if L == nil {
+		return
+	}
+	ctx := context.WithValue(context.Background(), bypassKey{}, true)
+	for key, c := range counts {
+		if c.suppressed == 0 {
+			continue
+		}
+		// 模块日志按模块的级别输出汇总
+		entry := logrus.NewEntry(L)
+		if key.module != "" {
+			entry = Named(key.module)
+		}
+		entry.WithContext(ctx).WithField(FieldSuppressed, c.suppressed).
+			Logf(key.level, "suppressed %d similar messages: %s", c.suppressed, key.message)
+	}
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/jiujuan/go-star/pkg/config"
+)
+
+func TestSampler(t *testing.T) {
+	Convey("相同日志采样", t, func() {
+		var buf bytes.Buffer
+		L = logrus.New()
+		L.SetOutput(io.Discard)
+		L.AddHook(&outputHook{outputs: []*output{{w: &buf, minLevel: logrus.TraceLevel, f: &logrus.JSONFormatter{}}}})
+		syncModules()
+		sampler.Update(config.SamplingConfig{
+			Enable:   true,
+			Interval: time.Hour, // 由测试调用 Flush 结束窗口
+			Levels:   map[string]config.SamplingRule{"error": {First: 3, Thereafter: 5}},
+		})
+		Reset(func() { sampler.Update(config.SamplingConfig{}) })
+
+		lines := func() []map[string]interface{} {
+			var out []map[string]interface{}
+			sc := bufio.NewScanner(&buf)
+			for sc.Scan() {
+				var e map[string]interface{}
+				So(json.Unmarshal(sc.Bytes(), &e), ShouldBeNil)
+				out = append(out, e)
+			}
+			buf.Reset()
+			return out
+		}
+
+		Convey("前 first 条之后每 thereafter 条输出 1 条，窗口结束时输出汇总", func() {
+			for i := 0; i < 20; i++ {
+				L.Error("db down")
+			}
+			L.Error("other")
+			So(lines(), ShouldHaveLength, 3+3+1) // 第 1~3、8、13、18 条和 other
+
+			sampler.Flush()
+			out := lines()
+			So(out, ShouldHaveLength, 1)
+			So(out[0]["level"], ShouldEqual, "error")
+			So(out[0]["msg"], ShouldEqual, "suppressed 14 similar messages: db down")
+			So(out[0][FieldSuppressed], ShouldEqual, 14)
+
+			// 新窗口重新计数
+			L.Error("db down")
+			So(lines(), ShouldHaveLength, 1)
+		})
+
+		Convey("未配置的级别和不同模块分别处理", func() {
+			for i := 0; i < 10; i++ {
+				L.Warn("retry")
+			}
+			So(lines(), ShouldHaveLength, 10)
+
+			for i := 0; i < 4; i++ {
+				Named("db").Error("timeout")
+				Named("redis").Error("timeout")
+			}
+			So(lines(), ShouldHaveLength, 6)
+
+			sampler.Flush()
+			out := lines()
+			So(out, ShouldHaveLength, 2)
+			So(out[0][FieldModule], ShouldBeIn, "db", "redis")
+		})
+
+		Convey("关闭后全部输出", func() {
+			sampler.Update(config.SamplingConfig{})
+			for i := 0; i < 10; i++ {
+				L.Error("db down")
+			}
+			So(lines(), ShouldHaveLength, 10)
+		})
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package logger
+
+// watchSignals 非 unix 平台没有 SIGUSR1/SIGUSR2
+func watchSignals() (stop func()) {
+	return func() {}
+}
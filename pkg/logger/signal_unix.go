@@ -0,0 +1,42 @@
+//go:build unix
+
+package logger
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jiujuan/go-star/pkg/config"
+)
+
+// watchSignals SIGUSR1 把全局级别调详细一级，SIGUSR2 调低一级，log.level_ttl 后自动恢复
+func watchSignals() (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-ch:
+				delta := 1
+				if sig == syscall.SIGUSR2 {
+					delta = -1
+				}
+				ttl := config.LogSection.Get().LevelTTL
+				level, err := StepLevel(delta, ttl)
+				if err != nil {
+					Errorf("step log level on %s: %v", sig, err)
+					continue
+				}
+				Warnf("log level changed to %s by %s (revert after %s)", level, sig, ttl)
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
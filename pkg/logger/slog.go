@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SlogHandler 把 log/slog 的记录写入 L：级别对应 logrus 级别，属性转为字段，
+// 分组以 "." 连接为字段名前缀（如 req.method），ctx 中的请求字段一并带上。
+// Init 会把它设为 slog 的缺省 handler，标准库 log 的输出也随之写入 L。
+type SlogHandler struct {
+	fields logrus.Fields // WithAttrs 累积的字段
+	prefix string        // WithGroup 累积的前缀，如 "req."
+}
+
+// NewSlogHandler 创建写入 L 的 slog.Handler
+func NewSlogHandler() *SlogHandler {
+	return &SlogHandler{fields: logrus.Fields{}}
+}
+
+// Enabled 按 L 当前级别判断，运行时调整级别后立即生效
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return L != nil && L.IsLevelEnabled(toLogrusLevel(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if L == nil {
+		return nil
+	}
+	fields := make(logrus.Fields, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, h.prefix, a)
+		return true
+	})
+	entry := FromContext(ctx).WithFields(fields)
+	if !r.Time.IsZero() {
+		entry = entry.WithTime(r.Time)
+	}
+	entry.Log(toLogrusLevel(r.Level), r.Message)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	nh := h.clone()
+	for _, a := range attrs {
+		addAttr(nh.fields, nh.prefix, a)
+	}
+	return nh
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	nh := h.clone()
+	nh.prefix += name + "."
+	return nh
+}
+
+func (h *SlogHandler) clone() *SlogHandler {
+	fields := make(logrus.Fields, len(h.fields))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	return &SlogHandler{fields: fields, prefix: h.prefix}
+}
+
+// addAttr 展开属性，分组属性递归加前缀；空 key 的分组直接并入当前层
+func addAttr(fields logrus.Fields, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(fields, prefix, ga)
+		}
+		return
+	}
+	fields[prefix+a.Key] = a.Value.Any()
+}
+
+// toLogrusLevel slog 级别对应的 logrus 级别，低于 Debug 的记为 Trace
+func toLogrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level >= slog.LevelError:
+		return logrus.ErrorLevel
+	case level >= slog.LevelWarn:
+		return logrus.WarnLevel
+	case level >= slog.LevelInfo:
+		return logrus.InfoLevel
+	case level >= slog.LevelDebug:
+		return logrus.DebugLevel
+	default:
+		return logrus.TraceLevel
+	}
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSlogHandler(t *testing.T) {
+	Convey("slog 写入 logger.L", t, func() {
+		var buf bytes.Buffer
+		L = logrus.New()
+		L.SetOutput(&buf)
+		L.SetFormatter(&logrus.JSONFormatter{})
+		log := slog.New(NewSlogHandler())
+
+		last := func() map[string]interface{} {
+			var e map[string]interface{}
+			So(json.Unmarshal(buf.Bytes(), &e), ShouldBeNil)
+			buf.Reset()
+			return e
+		}
+
+		Convey("级别、属性、分组和 ctx 字段", func() {
+			ctx := WithContext(context.Background(), logrus.Fields{FieldRequestID: "req-1"})
+			log.With("component", "sync").WithGroup("job").
+				ErrorContext(ctx, "failed", "id", 7, slog.Group("retry", "n", 2, "wait", time.Second), "err", errors.New("boom"))
+
+			e := last()
+			So(e["level"], ShouldEqual, "error")
+			So(e["msg"], ShouldEqual, "failed")
+			So(e["component"], ShouldEqual, "sync")
+			So(e["job.id"], ShouldEqual, 7)
+			So(e["job.retry.n"], ShouldEqual, 2)
+			So(e["job.err"], ShouldEqual, "boom")
+			So(e[FieldRequestID], ShouldEqual, "req-1")
+		})
+
+		Convey("按 L 的级别过滤", func() {
+			log.Debug("hidden")
+			So(buf.Len(), ShouldEqual, 0)
+
+			L.SetLevel(logrus.DebugLevel)
+			log.Debug("shown")
+			So(last()["level"], ShouldEqual, "debug")
+			So(toLogrusLevel(slog.LevelDebug-4), ShouldEqual, logrus.TraceLevel)
+		})
+	})
+}
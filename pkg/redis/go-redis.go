@@ -2,14 +2,28 @@ package redis
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+
 	"github.com/jiujuan/go-star/pkg/config"
+	"github.com/jiujuan/go-star/pkg/graceful"
 )
 
+// Config Redis 配置，对应 redis 一节
+type Config struct {
+	Addr     string `mapstructure:"addr" validate:"required"`
+	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db" validate:"min=0"`
+	PoolSize int    `mapstructure:"pool_size" validate:"min=0" comment:"连接池大小，0 表示按 CPU 数计算"`
+}
+
+// Section redis 配置节
+var Section = config.NewSection("redis", "Redis", Config{Addr: "127.0.0.1:6379", PoolSize: 20})
+
 // Client 在原有 *redis.Client 上再包一层，方便后期扩展（如链路追踪、指标）
 type Client struct {
 	*redis.Client
@@ -19,18 +33,19 @@ type Client struct {
 var Rdb *Client
 
 // ---------- 初始化 ----------
-func New(cfg *config.Config) *Client {
+func New(cfg Config) *Client {
 	opts := &redis.Options{
-		Addr:         cfg.Redis.Addr,
-		Password:     cfg.Redis.Password,
-		DB:           cfg.Redis.DB,
-		PoolSize:     cfg.Redis.PoolSize,
+		Addr:         cfg.Addr,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
 		MinIdleConns: 5,
 		MaxRetries:   3,
 		ReadTimeout:  3 * time.Second,
 		WriteTimeout: 3 * time.Second,
 	}
 	r := redis.NewClient(opts)
+	r.AddHook(logHook{})
 	// 探活
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -115,7 +130,7 @@ func (r *Client) SIsMember(ctx context.Context, key string, member interface{})
 }
 
 // ---------- Sorted Set ----------
-func (r *Client) ZAdd(ctx context.Context, key string, members ...*redis.Z) (int64, error) {
+func (r *Client) ZAdd(ctx context.Context, key string, members ...redis.Z) (int64, error) {
 	return r.Client.ZAdd(ctx, key, members...).Result()
 }
 
@@ -187,4 +202,11 @@ end`
 var ErrNil = redis.Nil
 
 // ---------- Fx 模块 ----------
-var Module = fx.Provide(New)
\ No newline at end of file
+// 进程退出时在 close 阶段关闭连接池
+var Module = fx.Options(
+	Section.Provide(),
+	fx.Provide(New),
+	fx.Invoke(func(c *Client, m *graceful.Manager) {
+		m.Register("redis", graceful.CloserShutdown(c))
+	}),
+)
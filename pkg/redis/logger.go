@@ -0,0 +1,60 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/jiujuan/go-star/pkg/logger"
+)
+
+// logHook 通过 redis 模块日志器记录命令：失败记 warn（redis.Nil 除外），其余记 debug。
+// 只记录命令名和耗时，不记录参数，避免写出缓存的值。
+type logHook struct{}
+
+func (logHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (logHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		logCmd(ctx, cmd.FullName(), time.Since(start), err)
+		return err
+	}
+}
+
+func (logHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		logCmd(ctx, "pipeline", time.Since(start), err, logrus.Fields{"cmds": len(cmds)})
+		return err
+	}
+}
+
+func logCmd(ctx context.Context, name string, elapsed time.Duration, err error, extra ...logrus.Fields) {
+	failed := err != nil && !errors.Is(err, redis.Nil)
+	entry := logger.NamedFromContext(ctx, "redis")
+	if !failed && !entry.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+	entry = entry.WithFields(logrus.Fields{
+		"cmd":        name,
+		"latency_ms": float64(elapsed.Microseconds()) / 1000,
+	})
+	for _, f := range extra {
+		entry = entry.WithFields(f)
+	}
+	if failed {
+		entry.WithError(err).Warn("redis error")
+		return
+	}
+	entry.Debug("redis")
+}
+
+var _ redis.Hook = logHook{}
@@ -31,8 +31,25 @@ func New() Validator {
 	}
 	
 	// 注册默认的字段名获取函数
-	v.validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
-		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+	v.validate.RegisterTagNameFunc(tagNameFunc("json"))
+	
+	// 注册默认的自定义验证规则
+	v.registerDefaultValidations()
+	
+	return v
+}
+
+// NewWithTagName 创建验证器，错误命名空间中的字段名取自指定 tag（如 mapstructure）
+func NewWithTagName(tag string) Validator {
+	v := New().(*ValidatorImpl)
+	v.validate.RegisterTagNameFunc(tagNameFunc(tag))
+	return v
+}
+
+// tagNameFunc 按 tag 取字段名，tag 为空时使用字段名
+func tagNameFunc(tag string) validator.TagNameFunc {
+	return func(fld reflect.StructField) string {
+		name := strings.SplitN(fld.Tag.Get(tag), ",", 2)[0]
 		if name == "-" {
 			return ""
 		}
@@ -40,12 +57,7 @@ func New() Validator {
 			return fld.Name
 		}
 		return name
-	})
-	
-	// 注册默认的自定义验证规则
-	v.registerDefaultValidations()
-	
-	return v
+	}
 }
 
 // Validate 验证结构体